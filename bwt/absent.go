@@ -0,0 +1,74 @@
+package bwt
+
+import "sort"
+
+// MinimalAbsentWords returns the minimal absent words of x of length at
+// most maxLen, sorted: the strings aub that do not occur in x while au and
+// ub do, where a and b are characters of x and u is a string. The
+// alphabet is x's own, so a minimal absent word has at least two
+// characters.
+//
+// The occurring strings u are enumerated by backward search, like the
+// k-mers of an index. The rows of u's interval are sorted by the
+// character that follows u, so splitting the interval at those characters
+// gives the intervals of the strings ub, and aub is absent exactly when
+// extending ub's interval with a leaves it empty. Each enumerated string
+// costs its number of occurrences and sigma^2 extensions.
+func MinimalAbsentWords(x string, maxLen int) []string {
+	words := []string{}
+	if maxLen < 2 || len(x) == 0 {
+		return words
+	}
+	index := BuildIndex(x)
+	sigma := index.alpha.Size()
+
+	u := []byte{} // the characters of u, in reverse
+	var visit func(L, R int)
+	visit = func(L, R int) {
+		m := len(u)
+		// Split [L, R) by the character after u; the row whose suffix
+		// is u itself comes first and has none.
+		type group struct {
+			b    byte
+			L, R int
+		}
+		groups := []group{}
+		for r := L; r < R; r++ {
+			pos := int(index.sa[r]) + m
+			if pos >= len(x) {
+				continue
+			}
+			if b := x[pos]; len(groups) > 0 && groups[len(groups)-1].b == b {
+				groups[len(groups)-1].R = r + 1
+			} else {
+				groups = append(groups, group{b, r, r + 1})
+			}
+		}
+
+		for a := byte(1); int(a) < sigma; a++ {
+			l, r := extendInterval(a, L, R, index.ctab, index.otab)
+			if l >= r {
+				continue // au does not occur
+			}
+			for _, g := range groups {
+				if gl, gr := extendInterval(a, g.L, g.R, index.ctab, index.otab); gl >= gr {
+					w := make([]byte, 0, m+2)
+					w = append(w, index.alpha.letters[a])
+					for i := m - 1; i >= 0; i-- {
+						w = append(w, u[i])
+					}
+					words = append(words, string(append(w, g.b)))
+				}
+			}
+			if m+3 <= maxLen {
+				u = append(u, index.alpha.letters[a])
+				visit(l, r)
+				u = u[:m]
+			}
+		}
+	}
+	visit(0, len(index.bwt))
+
+	sort.Strings(words)
+	return words
+}
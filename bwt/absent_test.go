@@ -0,0 +1,51 @@
+package bwt
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// naiveMinimalAbsentWords enumerates every string over x's alphabet of
+// length 2 to maxLen and keeps the absent ones whose two longest proper
+// substrings occur.
+func naiveMinimalAbsentWords(x string, maxLen int) []string {
+	alpha := NewAlphabet(x).letters[1:]
+	words := []string{}
+	var gen func(w string)
+	gen = func(w string) {
+		if len(w) >= 2 && !strings.Contains(x, w) &&
+			strings.Contains(x, w[1:]) && strings.Contains(x, w[:len(w)-1]) {
+			words = append(words, w)
+		}
+		if len(w) < maxLen {
+			for _, a := range alpha {
+				gen(w + string(a))
+			}
+		}
+	}
+	gen("")
+	sort.Strings(words)
+	return words
+}
+
+func TestMinimalAbsentWords(t *testing.T) {
+	if words, expected := MinimalAbsentWords("abaab", 5), []string{"aaa", "aaba", "bab", "bb"}; !reflect.DeepEqual(words, expected) {
+		t.Errorf("Expected minimal absent words %v, got %v", expected, words)
+	}
+
+	rng := newRandomSeed(t)
+	xs := []string{"", "a", "aaaa", "mississippi"}
+	for i := 0; i < 20; i++ {
+		xs = append(xs, randomStringN(1+rng.Intn(30), "acgt"[:1+rng.Intn(4)], rng))
+	}
+	for _, x := range xs {
+		for maxLen := 0; maxLen <= 5; maxLen++ {
+			words, expected := MinimalAbsentWords(x, maxLen), naiveMinimalAbsentWords(x, maxLen)
+			if !reflect.DeepEqual(words, expected) {
+				t.Errorf("Expected minimal absent words %v of %q up to length %d, got %v", expected, x, maxLen, words)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package bwt
+
+import "fmt"
+
+// Alphabet maps the characters that occur in a string to the compact range
+// [1, Size()), preserving their order. Symbol 0 is reserved for the
+// sentinel, so tables over a mapped string only need Size() rows, rather
+// than one for each of the 256 possible bytes.
+type Alphabet struct {
+	symbols [256]byte // symbols[a] is the symbol for a, or 0 if a isn't in the alphabet
+	letters []byte    // letters[s] is the byte for symbol s
+}
+
+// NewAlphabet returns the alphabet of the characters that occur in x.
+// Since the sentinel needs a symbol of its own, x can contain at most 255
+// distinct characters; NewAlphabet panics if it contains all 256.
+func NewAlphabet(x string) *Alphabet {
+	var occurs [256]bool
+	for i := 0; i < len(x); i++ {
+		occurs[x[i]] = true
+	}
+	return alphabetFromOccurrences(&occurs)
+}
+
+// alphabetFromOccurrences returns the alphabet of the bytes a with
+// occurs[a] set, with NewAlphabet's limit on its size.
+func alphabetFromOccurrences(occurs *[256]bool) *Alphabet {
+	letters := []byte{0} // the sentinel
+	for a := 0; a < 256; a++ {
+		if occurs[a] {
+			letters = append(letters, byte(a))
+		}
+	}
+	if len(letters) > 256 {
+		panic("bwt: the alphabet has no room for the sentinel")
+	}
+	return alphabetFromLetters(letters)
+}
+
+// alphabetFromLetters builds the alphabet that maps letters[s] to s. The
+// first letter is the sentinel, and the remaining letters must be sorted.
+func alphabetFromLetters(letters []byte) *Alphabet {
+	alpha := &Alphabet{letters: letters}
+	for s := 1; s < len(letters); s++ {
+		alpha.symbols[letters[s]] = byte(s)
+	}
+	return alpha
+}
+
+// mapBwt returns the alphabet of the characters in the BWT y, other than
+// the zero byte of the sentinel, and y mapped to it. The sentinel stays 0,
+// so tables over the mapped BWT only need rows for the characters that
+// occur, instead of all 256 bytes.
+func mapBwt(y []byte) (*Alphabet, []byte) {
+	var occurs [256]bool
+	for _, a := range y {
+		occurs[a] = true
+	}
+	occurs[0] = false
+	alpha := alphabetFromOccurrences(&occurs)
+	b := make([]byte, len(y))
+	for i, a := range y {
+		b[i] = alpha.symbols[a]
+	}
+	return alpha, b
+}
+
+// Size returns the number of symbols in the alphabet, including the
+// sentinel.
+func (alpha *Alphabet) Size() int {
+	return len(alpha.letters)
+}
+
+// Map returns the symbol for a, and false if a is not in the alphabet.
+func (alpha *Alphabet) Map(a byte) (byte, bool) {
+	s := alpha.symbols[a]
+	return s, s != 0
+}
+
+// MapString maps the characters in x to their symbols. It is an error if
+// x contains characters that are not in the alphabet.
+func (alpha *Alphabet) MapString(x string) ([]byte, error) {
+	y := make([]byte, len(x))
+	for i := 0; i < len(x); i++ {
+		s, ok := alpha.Map(x[i])
+		if !ok {
+			return nil, fmt.Errorf("character %q at index %d is not in the alphabet", x[i], i)
+		}
+		y[i] = s
+	}
+	return y, nil
+}
+
+// Unmap maps the symbols in y back to the characters they represent. The
+// sentinel maps to the zero byte. It is an error if y contains symbols
+// outside the alphabet.
+func (alpha *Alphabet) Unmap(y []byte) (string, error) {
+	x := make([]byte, len(y))
+	for i, s := range y {
+		if int(s) >= len(alpha.letters) {
+			return "", fmt.Errorf("symbol %d at index %d is not in the alphabet", s, i)
+		}
+		x[i] = alpha.letters[s]
+	}
+	return string(x), nil
+}
@@ -0,0 +1,60 @@
+package bwt
+
+import (
+	"testing"
+)
+
+func TestAlphabet(t *testing.T) {
+	alpha := NewAlphabet("mississippi")
+	if alpha.Size() != 5 {
+		t.Errorf("Expected four letters and the sentinel, got size %d", alpha.Size())
+	}
+
+	y, err := alpha.MapString("missi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{2, 1, 4, 4, 1}
+	for i := range y {
+		if y[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, y)
+		}
+	}
+	if x, err := alpha.Unmap(y); err != nil || x != "missi" {
+		t.Errorf("Expected to get missi back, got %q (%v)", x, err)
+	}
+
+	if _, err := alpha.MapString("mix"); err == nil {
+		t.Errorf("Expected an error for a character outside the alphabet")
+	}
+	if _, err := alpha.Unmap([]byte{5}); err == nil {
+		t.Errorf("Expected an error for a symbol outside the alphabet")
+	}
+}
+
+func TestAlphabetRoundTrip(t *testing.T) {
+	rng := newRandomSeed(t)
+	all := make([]byte, 255)
+	for i := range all {
+		all[i] = byte(i + 1)
+	}
+	for _, x := range []string{"", "acgt", string(all), randomStringN(500, string(all), rng)} {
+		alpha := NewAlphabet(x)
+		y, err := alpha.MapString(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back, err := alpha.Unmap(y); err != nil || back != x {
+			t.Errorf("Expected %q back, got %q (%v)", x, back, err)
+		}
+	}
+
+	b := []byte(Bwt("gattaca"))
+	alpha, mapped := mapBwt(b)
+	if alpha.Size() != 5 {
+		t.Errorf("Expected four letters and the sentinel in the BWT, got %d", alpha.Size())
+	}
+	if y, _ := alpha.Unmap(reverseBwt(mapped, alpha.Size())); y != "gattaca" {
+		t.Errorf("Expected the mapped BWT to reverse to gattaca, got %q", y)
+	}
+}
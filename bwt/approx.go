@@ -0,0 +1,280 @@
+package bwt
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// CountWithTranspositions counts the positions in the text where a string
+// of the same length as p starts that can be turned into p with at most
+// maxSwaps edits, where an edit either substitutes a character or swaps
+// two adjacent characters, as in Damerau-Levenshtein distance without
+// insertions and deletions. Each edited character takes part in at most
+// one edit.
+//
+// The search is the usual branch-and-bound extension of backward search,
+// where a transposition extends the interval by two characters at once.
+// Different edit sequences can reach the same string, so the matched
+// intervals are collected by their start before they are counted.
+func CountWithTranspositions(index *Index, p string, maxSwaps int) int {
+	return countWithTranspositions(index, p, maxSwaps)
+}
+
+// CountWithTranspositionsBytes is CountWithTranspositions for a pattern
+// given as a byte slice.
+func CountWithTranspositionsBytes(index *Index, p []byte, maxSwaps int) int {
+	return countWithTranspositions(index, p, maxSwaps)
+}
+
+func countWithTranspositions[P pattern](index *Index, p P, maxSwaps int) int {
+	if len(p) > index.Len() {
+		return 0 // edits preserve the length, so p cannot fit
+	}
+	hits := map[int]int{} // L -> R for each matched interval
+
+	var search func(i, L, R, budget int)
+	search = func(i, L, R, budget int) {
+		if L >= R {
+			return
+		}
+		if i < 0 {
+			hits[L] = R
+			return
+		}
+
+		pi, ok := index.alpha.Map(p[i])
+		for a := byte(1); int(a) < index.alpha.Size(); a++ {
+			cost := 0
+			if !ok || a != pi {
+				cost = 1
+			}
+			if cost <= budget {
+				l, r := extendInterval(a, L, R, index.ctab, index.otab)
+				search(i-1, l, r, budget-cost)
+			}
+		}
+
+		// Swapping two equal characters is the same as matching them.
+		if budget > 0 && i > 0 && p[i] != p[i-1] {
+			l, r := index.extend(p[i-1], L, R)
+			l, r = index.extend(p[i], l, r)
+			search(i-2, l, r, budget-1)
+		}
+	}
+	search(len(p)-1, 0, len(index.bwt), maxSwaps)
+
+	count := 0
+	for L, R := range hits {
+		count += R - L
+	}
+	return count
+}
+
+// mismatchSearch calls visit with the suffix-array interval [L, R) of
+// every string in the text that has the same length as p and differs from
+// it in d <= k positions. It is backward search extended into a branch and
+// bound over all symbols at each position, pruned when the interval is
+// empty or the mismatch budget is spent. Each path through the recursion
+// spells a different string, so each interval is visited once.
+func mismatchSearch[P pattern](index *Index, p P, k int, visit func(L, R, d int)) {
+	tableMismatchSearch(p, len(index.bwt), index.alpha, index.ctab, index.otab, k, visit)
+}
+
+// tableMismatchSearch is mismatchSearch on the tables of a BWT of length
+// n over the alphabet alpha.
+func tableMismatchSearch[P pattern](p P, n int, alpha *Alphabet, ctab *CTab, otab rankTable, k int, visit func(L, R, d int)) {
+	if len(p) >= n {
+		return
+	}
+	var search func(i, L, R, d int)
+	search = func(i, L, R, d int) {
+		if L >= R {
+			return
+		}
+		if i < 0 {
+			visit(L, R, d)
+			return
+		}
+		pi, ok := alpha.Map(p[i])
+		for a := byte(1); int(a) < alpha.Size(); a++ {
+			cost := 0
+			if !ok || a != pi {
+				cost = 1
+			}
+			if d+cost <= k {
+				l, r := extendInterval(a, L, R, ctab, otab)
+				search(i-1, l, r, d+cost)
+			}
+		}
+	}
+	search(len(p)-1, 0, n, 0)
+}
+
+// ApproxHistogram counts the occurrences of p with up to maxMismatch
+// mismatches, by the number of mismatches: entry d of the result is the
+// number of positions where p occurs with exactly d mismatches. A negative
+// maxMismatch gives an empty histogram.
+func ApproxHistogram(index *Index, p string, maxMismatch int) []int {
+	return approxHistogram(index, p, maxMismatch)
+}
+
+// ApproxHistogramBytes is ApproxHistogram for a pattern given as a byte
+// slice.
+func ApproxHistogramBytes(index *Index, p []byte, maxMismatch int) []int {
+	return approxHistogram(index, p, maxMismatch)
+}
+
+func approxHistogram[P pattern](index *Index, p P, maxMismatch int) []int {
+	if maxMismatch < 0 {
+		return []int{}
+	}
+	hist := make([]int, maxMismatch+1)
+	mismatchSearch(index, p, maxMismatch, func(L, R, d int) {
+		hist[d] += R - L
+	})
+	return hist
+}
+
+// editSearch calls visit with the suffix-array interval of each string in
+// the text that is at most k edits from p, where an edit is a
+// substitution, an insertion or a deletion, together with the string and
+// its edit distance to p, until visit returns false. The string is a new
+// slice for each call, so visit may keep it.
+//
+// The strings are spelled by backward search, prepending one character at
+// a time, and each branch carries the column of edit distances between
+// the suffixes of p and the string spelled so far, computed from the
+// parent's column as in the dynamic program for edit distance. A branch
+// is cut when no entry of its column is within k, since prepending more
+// characters cannot lower them. Each string of the text is reached once,
+// through its own branch, so the search takes O(sigma*m) time for each
+// string of the text that is within k edits of a suffix of p.
+func editSearch[P pattern](index *Index, p P, k int, visit func(L, R int, w []byte, edits int) bool) {
+	if k < 0 {
+		return
+	}
+	m := len(p)
+	symbols := make([]byte, m) // 0, which matches no symbol, outside the alphabet
+	for j := range symbols {
+		symbols[j], _ = index.alpha.Map(p[j])
+	}
+	spelled := []byte{} // the string, in reverse
+
+	// col[j] is the edit distance between p[j:] and the spelled string.
+	var search func(L, R int, col []int) bool
+	search = func(L, R int, col []int) bool {
+		if col[0] <= k {
+			w := make([]byte, len(spelled))
+			for j, a := range spelled {
+				w[len(w)-1-j] = a
+			}
+			if !visit(L, R, w, col[0]) {
+				return false
+			}
+		}
+		next := make([]int, m+1)
+		for a := byte(1); int(a) < index.alpha.Size(); a++ {
+			l, r := extendInterval(a, L, R, index.ctab, index.otab)
+			if l >= r {
+				continue
+			}
+			next[m] = col[m] + 1
+			lowest := next[m]
+			for j := m - 1; j >= 0; j-- {
+				d := col[j+1] // p[j] matches a
+				if symbols[j] != a {
+					d++ // p[j] is substituted by a
+				}
+				if col[j]+1 < d {
+					d = col[j] + 1 // a is inserted in p
+				}
+				if next[j+1]+1 < d {
+					d = next[j+1] + 1 // p[j] is deleted
+				}
+				next[j] = d
+				if d < lowest {
+					lowest = d
+				}
+			}
+			if lowest > k {
+				continue
+			}
+			spelled = append(spelled, index.alpha.letters[a])
+			if !search(l, r, next) {
+				return false
+			}
+			spelled = spelled[:len(spelled)-1]
+		}
+		return true
+	}
+	col := make([]int, m+1)
+	for j := range col {
+		col[j] = m - j
+	}
+	search(0, len(index.bwt), col)
+}
+
+// ApproxFPRate estimates how often approximate search with an edit budget
+// of k finds a hit for a random pattern of length patternLen, which, since
+// the pattern has nothing to do with the text, is a spurious hit. The
+// patterns are drawn from rng with the characters in proportion to their
+// frequency in the text, so the estimate reflects the text's composition,
+// and the result is the fraction of the trials that hit, in [0, 1]. A
+// budget of k >= patternLen always hits, since the empty string is
+// patternLen edits away, and an empty text, or no trials, gives 0.
+func ApproxFPRate(index *Index, patternLen, k int, trials int, rng *rand.Rand) float64 {
+	n := len(index.bwt)
+	if trials <= 0 || n <= 1 {
+		return 0
+	}
+	cumsum := index.ctab.cumsum
+	p := make([]byte, patternLen)
+	hits := 0
+	for trial := 0; trial < trials; trial++ {
+		for i := range p {
+			// Row r >= 1 holds a suffix that starts with the symbol
+			// whose C-table range holds r.
+			r := 1 + rng.Intn(n-1)
+			a := sort.Search(len(cumsum), func(a int) bool { return cumsum[a] > r }) - 1
+			p[i] = index.alpha.letters[a]
+		}
+		editSearch(index, p, k, func(L, R int, w []byte, d int) bool {
+			hits++
+			return false
+		})
+	}
+	return float64(hits) / float64(trials)
+}
+
+// ClosestMatch returns a substring of the text that is as few edits from p
+// as possible, together with its edit distance to p. If p occurs in the
+// text, it is returned with zero edits. Otherwise, the approximate search
+// is repeated with an edit budget of 1, 2, ..., below len(p), since with
+// len(p) edits the empty string matches by deleting all of p; if several
+// substrings are equally close, one of them is returned.
+func ClosestMatch(index *Index, p string) (match string, edits int) {
+	return closestMatch(index, p)
+}
+
+// ClosestMatchBytes is ClosestMatch for a pattern given as a byte slice.
+// If p occurs in the text, the match is p itself, not a copy.
+func ClosestMatchBytes(index *Index, p []byte) (match []byte, edits int) {
+	return closestMatch(index, p)
+}
+
+func closestMatch[P pattern](index *Index, p P) (match P, edits int) {
+	if L, R := search(index, p); L < R {
+		return p, 0
+	}
+	for k := 1; k < len(p); k++ {
+		found := false
+		editSearch(index, p, k, func(L, R int, w []byte, d int) bool {
+			match, edits, found = P(w), d, true
+			return false
+		})
+		if found {
+			return match, edits
+		}
+	}
+	return P(""), len(p)
+}
@@ -0,0 +1,159 @@
+package bwt
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// substSwapDist returns the number of substitutions and adjacent swaps
+// needed to turn w into p, where len(w) == len(p).
+func substSwapDist(p, w string) int {
+	d := make([]int, len(p)+1)
+	for j := 1; j <= len(p); j++ {
+		d[j] = d[j-1]
+		if p[j-1] != w[j-1] {
+			d[j]++
+		}
+		if j > 1 && p[j-1] == w[j-2] && p[j-2] == w[j-1] && d[j-2]+1 < d[j] {
+			d[j] = d[j-2] + 1
+		}
+	}
+	return d[len(p)]
+}
+
+func TestCountWithTranspositions(t *testing.T) {
+	idx := BuildIndex("acgtacgt")
+	if count := CountWithTranspositions(idx, "agct", 1); count != 2 {
+		t.Errorf("Expected one swap to match acgt twice, got %d", count)
+	}
+	if count := CountWithTranspositions(idx, "agct", 0); count != 0 {
+		t.Errorf("Expected no exact matches of agct, got %d", count)
+	}
+
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := randomStringN(30, "acgt", rng)
+		idx := BuildIndex(x)
+		for j := 0; j < 5; j++ {
+			p := randomStringN(1+rng.Intn(6), "acgt", rng)
+			k := rng.Intn(3)
+
+			expected := 0
+			for pos := 0; pos+len(p) <= len(x); pos++ {
+				if substSwapDist(p, x[pos:pos+len(p)]) <= k {
+					expected++
+				}
+			}
+			if count := CountWithTranspositions(idx, p, k); count != expected {
+				t.Errorf("CountWithTranspositions(%q, %d) in %q = %d, expected %d",
+					p, k, x, count, expected)
+			}
+		}
+	}
+}
+
+// hamming returns the number of positions where p and w differ.
+func hamming(p, w string) int {
+	d := 0
+	for i := range p {
+		if p[i] != w[i] {
+			d++
+		}
+	}
+	return d
+}
+
+func TestApproxHistogram(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := randomStringN(40, "acgt", rng)
+		p := randomStringN(1+rng.Intn(5), "acgt", rng)
+		k := rng.Intn(3)
+		idx := BuildIndex(x)
+
+		expected := make([]int, k+1)
+		for pos := 0; pos+len(p) <= len(x); pos++ {
+			if d := hamming(p, x[pos:pos+len(p)]); d <= k {
+				expected[d]++
+			}
+		}
+		hist := ApproxHistogram(idx, p, k)
+		for d := range expected {
+			if hist[d] != expected[d] {
+				t.Errorf("Histogram for %q in %q: %v, expected %v", p, x, hist, expected)
+				break
+			}
+		}
+	}
+
+	if hist := ApproxHistogram(BuildIndex("acgt"), "ac", -2); len(hist) != 0 {
+		t.Errorf("Expected an empty histogram for a negative mismatch bound, got %v", hist)
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		w := randomStringN(12, "acgt", rng)
+		x, _ := randomStringWithMotifs(100, "acgt", []string{w}, rng)
+		idx := BuildIndex(x)
+
+		if match, edits := ClosestMatch(idx, w); match != w || edits != 0 {
+			t.Errorf("Expected %q to match itself, got %q with %d edits", w, match, edits)
+		}
+
+		// Plant a typo that makes the pattern absent from the text
+		p := []byte(w)
+		j := rng.Intn(len(p))
+		p[j] = "ACGT"[rng.Intn(4)]
+		// w is one edit away, but so may be other substrings, such as w
+		// without the typo'd character at either end.
+		if match, edits := ClosestMatch(idx, string(p)); edits != 1 || editDistance(match, string(p)) != 1 || !strings.Contains(x, match) {
+			t.Errorf("Expected %q to be one edit from a substring like %q, got %q with %d edits", p, w, match, edits)
+		}
+	}
+
+	// A pattern with no character in the text is only matched by the
+	// empty string, which the search reaches without trying every
+	// budget's branching.
+	long := strings.Repeat("n", 40)
+	if match, edits := ClosestMatch(BuildIndex(randomStringN(1000, "acgt", rng)), long); edits != len(long) || len(match) > len(long) {
+		t.Errorf("Expected %d edits for %q, got %q with %d", len(long), long, match, edits)
+	}
+
+	match, edits := ClosestMatch(BuildIndex("acgt"), "xyz")
+	if edits != 3 || editDistance(match, "xyz") != 3 || !strings.Contains("acgt", match) {
+		t.Errorf("Expected a match three edits away, got %q with %d edits", match, edits)
+	}
+}
+
+func TestApproxFPRate(t *testing.T) {
+	rng := newRandomSeed(t)
+	idx := BuildIndex(randomStringN(1000, "acgt", rng))
+	seed := rng.Int63()
+	var rates []float64
+	for k := 0; k <= 3; k++ {
+		// The same seed gives the same patterns for every budget.
+		rate := ApproxFPRate(idx, 8, k, 200, rand.New(rand.NewSource(seed)))
+		if rate < 0 || rate > 1 {
+			t.Fatalf("Expected a rate in [0, 1] for k = %d, got %v", k, rate)
+		}
+		rates = append(rates, rate)
+	}
+	for k := 1; k < len(rates); k++ {
+		if rates[k] < rates[k-1] {
+			t.Errorf("Expected the rate to grow with k, got %v", rates)
+		}
+	}
+	if rates[3] <= rates[0] {
+		t.Errorf("Expected more hits with three edits than none, got %v", rates)
+	}
+
+	if rate := ApproxFPRate(idx, 3, 3, 10, rng); rate != 1 {
+		t.Errorf("Expected every pattern to hit with k = patternLen, got %v", rate)
+	}
+	if rate := ApproxFPRate(BuildIndex(""), 3, 1, 10, rng); rate != 0 {
+		t.Errorf("Expected no hits in an empty text, got %v", rate)
+	}
+}
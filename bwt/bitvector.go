@@ -0,0 +1,192 @@
+package bwt
+
+import "math/bits"
+
+// The select directory of a Bitvector splits the set bits into blocks of
+// selectStep and groups of selectGroup. A block or group whose bits span
+// more than selectSparse or selectGroupSparse words stores the positions
+// of its bits outright.
+const (
+	selectStep        = 512
+	selectSparse      = 512
+	selectGroup       = 16
+	selectGroupSparse = 16
+)
+
+// Bitvector is a fixed-length bit vector with rank and select queries,
+// such as the marks of the sampled rows in a compact index. Rank takes
+// constant time from a directory of the set bits before each 64-bit word.
+//
+// Select takes constant time from a two-level directory. The set bits are
+// split into blocks of 512, and a block that spans more than 512 words
+// stores the positions of its bits; there are at most n/(512*64) such
+// blocks, so that takes at most one int per word. The other blocks are
+// split into groups of 16 bits, and each group stores the word its first
+// bit is in, relative to the block, in 16 bits. A group that spans more
+// than 16 words, again at most one per 16 words, stores the positions of
+// its bits relative to the block instead, and the bit of any other group
+// is found by scanning at most 16 words.
+type Bitvector struct {
+	n         int
+	words     []uint64
+	ranks     []int         // ranks[w] is the number of set bits in words[:w]
+	blocks    []selectBlock // the select directory of every selectStep set bits
+	explicit  []int         // the positions of the set bits of the sparse blocks
+	groups    []uint16      // per group, its first word, or sparseGroup and the index of its positions
+	groupOnes []uint16      // the positions of the bits of the sparse groups, relative to their block
+}
+
+// selectBlock is the select directory entry of a block of set bits: the
+// word its first bit is in, and where its bits' positions start in
+// explicit, or, for a dense block, where its groups start in groups and
+// its sparse groups' positions in groupOnes. A sparse block has ones -1.
+type selectBlock struct {
+	word, first, ones int
+}
+
+// sparseGroup marks the entry of a sparse group in Bitvector.groups; the
+// other bits are the index of its positions in groupOnes, relative to its
+// block.
+const sparseGroup = 1 << 15
+
+// NewBitvector returns the bit vector of length n with the bits at the
+// positions in ones set. It panics if a position is not in [0, n).
+func NewBitvector(n int, ones []int) *Bitvector {
+	b := newBitvector(n)
+	for _, i := range ones {
+		if i < 0 || i >= n {
+			panic("bwt: bit position out of range")
+		}
+		b.set(i)
+	}
+	b.finish()
+	return b
+}
+
+// newBitvector returns a bit vector of length n with no bits set. The
+// bits are then set with set, and finish must be called before queries.
+func newBitvector(n int) *Bitvector {
+	return &Bitvector{n: n, words: make([]uint64, (n+63)/64)}
+}
+
+func (b *Bitvector) set(i int) {
+	b.words[i/64] |= 1 << (i % 64)
+}
+
+// finish computes the rank directory and the select directory. It must
+// be called after the last set.
+func (b *Bitvector) finish() {
+	b.ranks = make([]int, len(b.words)+1)
+	b.blocks, b.explicit, b.groups, b.groupOnes = nil, nil, nil, nil
+	ones := make([]int, 0, selectStep) // the set bits of the current block
+	for w, word := range b.words {
+		b.ranks[w+1] = b.ranks[w] + bits.OnesCount64(word)
+		for ; word != 0; word &= word - 1 {
+			if ones = append(ones, w*64+bits.TrailingZeros64(word)); len(ones) == selectStep {
+				b.addBlock(ones)
+				ones = ones[:0]
+			}
+		}
+	}
+	if len(ones) > 0 {
+		b.addBlock(ones)
+	}
+}
+
+// addBlock adds the select directory of the block of set bits at the
+// positions in ones.
+func (b *Bitvector) addBlock(ones []int) {
+	word := ones[0] / 64
+	if ones[len(ones)-1]/64-word >= selectSparse {
+		b.blocks = append(b.blocks, selectBlock{word, len(b.explicit), -1})
+		b.explicit = append(b.explicit, ones...)
+		return
+	}
+	b.blocks = append(b.blocks, selectBlock{word, len(b.groups), len(b.groupOnes)})
+	base := len(b.groupOnes)
+	for g := 0; g < len(ones); g += selectGroup {
+		group := ones[g:]
+		if len(group) > selectGroup {
+			group = group[:selectGroup]
+		}
+		if group[len(group)-1]/64-group[0]/64 >= selectGroupSparse {
+			b.groups = append(b.groups, sparseGroup|uint16(len(b.groupOnes)-base))
+			for _, i := range group {
+				b.groupOnes = append(b.groupOnes, uint16(i-word*64))
+			}
+		} else {
+			b.groups = append(b.groups, uint16(group[0]/64-word))
+		}
+	}
+}
+
+// Len returns the length of the bit vector.
+func (b *Bitvector) Len() int {
+	return b.n
+}
+
+// Ones returns the number of set bits.
+func (b *Bitvector) Ones() int {
+	return b.ranks[len(b.words)]
+}
+
+// Get returns bit i.
+func (b *Bitvector) Get(i int) bool {
+	return b.words[i/64]&(1<<(i%64)) != 0
+}
+
+// Rank returns the number of set bits in [0, i).
+func (b *Bitvector) Rank(i int) int {
+	w, r := i/64, i%64
+	if r == 0 {
+		return b.ranks[w]
+	}
+	return b.ranks[w] + bits.OnesCount64(b.words[w]&(1<<r-1))
+}
+
+// Select returns the position of set bit j, counting from zero, so
+// Rank(Select(j)) == j. It panics if j is not in [0, Ones()).
+func (b *Bitvector) Select(j int) int {
+	if j < 0 || j >= b.Ones() {
+		panic("bwt: select of a missing bit")
+	}
+	block := b.blocks[j/selectStep]
+	r := j % selectStep
+	if block.ones < 0 {
+		return b.explicit[block.first+r]
+	}
+	group := b.groups[block.first+r/selectGroup]
+	if group&sparseGroup != 0 {
+		return block.word*64 + int(b.groupOnes[block.ones+int(group&^sparseGroup)+r%selectGroup])
+	}
+	// The group's bits are in at most selectGroupSparse words from its
+	// first one.
+	lo := block.word + int(group)
+	for b.ranks[lo+1] <= j {
+		lo++
+	}
+	word := b.words[lo]
+	for k := j - b.ranks[lo]; k > 0; k-- {
+		word &= word - 1 // clear the lowest set bit
+	}
+	return lo*64 + bits.TrailingZeros64(word)
+}
+
+// SizeBytes returns the size of the bit vector's payload in bytes.
+func (b *Bitvector) SizeBytes() int {
+	return len(b.words)*8 + len(b.ranks)*intSize + b.selectSizeBytes()
+}
+
+// selectSizeBytes returns the size of the select directory in bytes.
+func (b *Bitvector) selectSizeBytes() int {
+	return (3*len(b.blocks)+len(b.explicit))*intSize + (len(b.groups)+len(b.groupOnes))*2
+}
+
+// selectSizeBound returns an upper bound on selectSizeBytes for a bit
+// vector of length n, whatever bits are set: a block per 512 bits and a
+// group per 16, and since a sparse block or group spans more than 512 or
+// 16 words, at most one stored position of each kind per word.
+func selectSizeBound(n int) int {
+	words := (n + 63) / 64
+	return (3*((n+selectStep-1)/selectStep)+words)*intSize + ((n+selectGroup-1)/selectGroup+words)*2
+}
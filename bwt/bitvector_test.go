@@ -0,0 +1,54 @@
+package bwt
+
+import "testing"
+
+func TestBitvector(t *testing.T) {
+	rng := newRandomSeed(t)
+	// The larger vectors have both dense and sparse blocks and groups in
+	// the select directory.
+	for _, n := range []int{0, 1, 63, 64, 65, 1000, 5000, 100000} {
+		for _, density := range []int{1, 2, 32, 48, 64, 1000} {
+			bits := make([]bool, n)
+			ones := []int{}
+			for i := range bits {
+				if rng.Intn(density) == 0 {
+					bits[i] = true
+					ones = append(ones, i)
+				}
+			}
+			b := NewBitvector(n, ones)
+			if b.Len() != n || b.Ones() != len(ones) {
+				t.Fatalf("Expected %d bits with %d set, got %d with %d", n, len(ones), b.Len(), b.Ones())
+			}
+			rank := 0
+			for i := 0; i <= n; i++ {
+				if got := b.Rank(i); got != rank {
+					t.Fatalf("Expected Rank(%d) = %d, got %d", i, rank, got)
+				}
+				if i < n {
+					if b.Get(i) != bits[i] {
+						t.Fatalf("Expected bit %d to be %v", i, bits[i])
+					}
+					if bits[i] {
+						rank++
+					}
+				}
+			}
+			for j, i := range ones {
+				if got := b.Select(j); got != i {
+					t.Fatalf("Expected Select(%d) = %d, got %d", j, i, got)
+				}
+			}
+			if size, bound := b.selectSizeBytes(), selectSizeBound(n); size > bound {
+				t.Errorf("Expected a select directory of at most %d bytes, got %d", bound, size)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for selecting a missing bit")
+		}
+	}()
+	NewBitvector(10, []int{3}).Select(1)
+}
@@ -1,9 +1,166 @@
 package bwt
 
+import "fmt"
+
+// Bwt computes the Burrows-Wheeler transform of x. The sentinel is added
+// implicitly, so the result is one character longer than x and contains
+// a single zero byte where the sentinel ends up. The string x itself
+// should not contain zero bytes.
 func Bwt(x string) string {
-	return "FIXME"
+	return string(bwtFromSA(x, PrefixDoubling(x)))
+}
+
+// BwtWithSA computes the Burrows-Wheeler transform of x like Bwt and also
+// returns the suffix array it was built from, the same as PrefixDoubling
+// returns, so callers that need both only sort the suffixes once.
+func BwtWithSA(x string) (bwt string, sa []int32) {
+	sa = PrefixDoubling(x)
+	return string(bwtFromSA(x, sa)), sa
+}
+
+// bwtFromSA builds the BWT of x from its suffix array.
+func bwtFromSA(x string, sa []int32) []byte {
+	b := make([]byte, len(sa))
+	for i, j := range sa {
+		if j == 0 {
+			b[i] = 0 // the sentinel
+		} else {
+			b[i] = x[j-1]
+		}
+	}
+	return b
+}
+
+// BwtOrder computes the Burrows-Wheeler transform of x like Bwt, with the
+// sentinel sorted according to order. The sentinel is still represented
+// by the zero byte in the result.
+func BwtOrder(x string, order SentinelOrder) string {
+	return string(bwtFromSA(x, PrefixDoublingOrder(x, order)))
+}
+
+// RbwtOrder reverses a transform computed by BwtOrder with the same order.
+func RbwtOrder(y string, order SentinelOrder) string {
+	if order == SentinelFirst {
+		return Rbwt(y)
+	}
+
+	// With the sentinel last, the rotation that starts with it is the
+	// last row, so it sorts after every character instead of before
+	// them: the C-table must not count it among the characters smaller
+	// than a, and its own rotations start at the last row.
+	alpha, b := mapBwt([]byte(y))
+	cumsum := NewCTab(b, alpha.Size()).cumsum
+	for a := 1; a < len(cumsum); a++ {
+		cumsum[a]--
+	}
+	cumsum[0] = len(b) - 1
+	x := make([]byte, len(b)-1)
+	walkLF(x, b, countingLF(b, cumsum), len(b)-1)
+	unmapInPlace(x, alpha)
+	return string(x)
+}
+
+// countingLF computes the LF-mapping of every row of b, where cumsum[a]
+// is the first row of the rotations that start with a, in a single
+// counting pass: the k'th a in b maps to row cumsum[a] + k. Besides the
+// n ints of the result, it only needs a count per symbol, so a one-shot
+// walk over a BWT does not need an O-table.
+func countingLF(b []byte, cumsum []int) []int {
+	next := append([]int{}, cumsum...)
+	lf := make([]int, len(b))
+	for i, a := range b {
+		lf[i] = next[a]
+		next[a]++
+	}
+	return lf
+}
+
+// walkLF fills x with the len(x) symbols that precede row i, walking
+// backwards from it along lf.
+func walkLF(x, b []byte, lf []int, i int) {
+	for j := len(x) - 1; j >= 0; j-- {
+		x[j] = b[i]
+		i = lf[i]
+	}
 }
 
+// Rbwt reverses the Burrows-Wheeler transform, i.e., if y = Bwt(x) then
+// Rbwt(y) == x. The sentinel is removed from the result. The BWT is first
+// mapped to the alphabet of the characters in it, so the tables used for
+// the reversal have a row for each character that occurs, such as five
+// for DNA with the sentinel, rather than for all 256 bytes.
 func Rbwt(y string) string {
-	return "FIXME"
+	alpha, b := mapBwt([]byte(y))
+	x := reverseBwt(b, alpha.Size())
+	unmapInPlace(x, alpha)
+	return string(x)
+}
+
+// RbwtFrom reverses the BWT y by LF-walking backwards from startRow
+// rather than from row 0. The walk produces the len(y)-1 characters that
+// cyclically precede the rotation at startRow, so the row's rotation is its
+// first character followed by the result. In a BWT with the sentinel
+// first, row 0 is the rotation that starts with the sentinel, so
+// RbwtFrom(y, 0) is Rbwt(y); for a cyclic BWT, without a sentinel, the
+// start row picks which rotation is reconstructed. Only the sentinel may
+// be a zero byte in y. RbwtFrom panics if startRow is not a row of y,
+// which no row is if y is empty.
+func RbwtFrom(y []byte, startRow int) string {
+	if startRow < 0 || startRow >= len(y) {
+		panic("bwt: start row out of range")
+	}
+	alpha, b := mapBwt(y)
+	x := make([]byte, len(y)-1)
+	reverseBwtFrom(x, b, alpha.Size(), startRow)
+	unmapInPlace(x, alpha)
+	return string(x)
+}
+
+// RbwtInto reverses the Burrows-Wheeler transform y like Rbwt, but writes
+// the result into dst instead of allocating it, so the buffer can be
+// reused. It returns the number of bytes written, len(y)-1, and an error
+// if dst is too small.
+func RbwtInto(dst, y []byte) (int, error) {
+	if len(y) == 0 {
+		return 0, fmt.Errorf("a BWT must contain the sentinel")
+	}
+	if len(dst) < len(y)-1 {
+		return 0, fmt.Errorf("buffer of length %d is too small for %d bytes", len(dst), len(y)-1)
+	}
+	alpha, b := mapBwt(y)
+	reverseBwtInto(dst[:len(y)-1], b, alpha.Size())
+	unmapInPlace(dst[:len(y)-1], alpha)
+	return len(y) - 1, nil
+}
+
+// unmapInPlace maps the symbols in x back to the characters of alpha.
+func unmapInPlace(x []byte, alpha *Alphabet) {
+	for i, s := range x {
+		x[i] = alpha.letters[s]
+	}
+}
+
+// reverseBwt reverses the BWT b over an alphabet of size asize, where the
+// sentinel is the symbol 0.
+func reverseBwt(b []byte, asize int) []byte {
+	x := make([]byte, len(b)-1)
+	reverseBwtInto(x, b, asize)
+	return x
+}
+
+// reverseBwtInto reverses the BWT b into x, which must have length
+// len(b)-1.
+func reverseBwtInto(x, b []byte, asize int) {
+	// Row 0 is the rotation that starts with the sentinel, so its last
+	// character is the last character of x. From there, LF-mapping walks
+	// x backwards.
+	reverseBwtFrom(x, b, asize, 0)
+}
+
+// reverseBwtFrom fills x with the len(x) characters that precede row i,
+// walking backwards from it with LF-mapping. The walk visits each row at
+// most once, so the LF-mapping comes from a counting pass rather than the
+// O-table that BuildLFArray needs.
+func reverseBwtFrom(x, b []byte, asize, i int) {
+	walkLF(x, b, countingLF(b, NewCTab(b, asize).cumsum), i)
 }
@@ -1,6 +1,8 @@
 package bwt
 
 import (
+	"reflect"
+	"sort"
 	"testing"
 )
 
@@ -15,3 +17,71 @@ func TestBwt(t *testing.T) {
 		}
 	}
 }
+
+func TestBwtWithSA(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "mississippi", randomStringN(200, "acgt", rng)} {
+		bwt, sa := BwtWithSA(x)
+		if expected := Bwt(x); bwt != expected {
+			t.Errorf("Expected BWT %q of %q, got %q", expected, x, bwt)
+		}
+		if expected := PrefixDoubling(x); !reflect.DeepEqual(sa, expected) {
+			t.Errorf("Expected suffix array %v of %q, got %v", expected, x, sa)
+		}
+	}
+}
+
+func TestRbwtInto(t *testing.T) {
+	rng := newRandomSeed(t)
+	buf := make([]byte, 20)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(rng.Intn(21), "acgt", rng)
+		n, err := RbwtInto(buf, []byte(Bwt(x)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(x) || string(buf[:n]) != x {
+			t.Errorf("Expected %q, got %q", x, buf[:n])
+		}
+	}
+
+	if _, err := RbwtInto(make([]byte, 3), []byte(Bwt("acgt"))); err == nil {
+		t.Errorf("Expected an error for a too small buffer")
+	}
+	if _, err := RbwtInto(buf, nil); err == nil {
+		t.Errorf("Expected an error for an empty BWT")
+	}
+}
+
+func TestRbwtFrom(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(1+rng.Intn(20), "acgt", rng)
+		if z := RbwtFrom([]byte(Bwt(x)), 0); z != x {
+			t.Errorf("Expected row 0 to give %q, got %q", x, z)
+		}
+
+		// The cyclic BWT of x, from its sorted rotations
+		rots := make([]string, len(x))
+		for j := range x {
+			rots[j] = x[j:] + x[:j]
+		}
+		sort.Strings(rots)
+		y := make([]byte, len(x))
+		for j, rot := range rots {
+			y[j] = rot[len(rot)-1]
+		}
+
+		row := rng.Intn(len(x))
+		if z := RbwtFrom(y, row); z != rots[row][1:] {
+			t.Errorf("Expected row %d of %q to give %q, got %q", row, x, rots[row][1:], z)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a start row outside the BWT")
+		}
+	}()
+	RbwtFrom(nil, 0)
+}
@@ -0,0 +1,179 @@
+package bwt
+
+// CDAWGraph is a compact directed acyclic word graph: the suffix tree of a
+// string with its isomorphic subtrees merged. Its internal nodes are the
+// maximal repeats of the string, the source is the empty string, and all
+// leaves are merged into a single sink. Every substring of the string is
+// spelled by a path from the source, and the number of occurrences of a
+// substring is the number of occurrences of the node the path ends at, or
+// of the node at the end of the edge it ends inside.
+//
+// The edge labels at the sink end in the sentinel, a zero byte.
+type CDAWGraph struct {
+	text  string // the string with the sentinel
+	nodes []cdawgNode
+}
+
+type cdawgNode struct {
+	count int // the number of occurrences of the node's strings
+	edges map[byte]cdawgEdge
+}
+
+// cdawgEdge is labelled text[start:end] and goes to target.
+type cdawgEdge struct {
+	start, end int32
+	target     int
+}
+
+// cdawgSource is the source node. The sink is the last node.
+const cdawgSource = 0
+
+// CDAWG builds the compact directed acyclic word graph for x from its
+// suffix array and lcp array. Each internal node of the suffix tree, an
+// lcp interval, is a right-maximal repeat. Its subtree is isomorphic to
+// that of its left extension whenever all its occurrences are preceded by
+// the same character, so each interval maps to the interval it reaches by
+// extending it backwards, with LF-mapping, until the BWT in the interval
+// has more than one character: the maximal repeat that is its node in the
+// graph. The edges of a node are those of its repeat in the suffix tree,
+// with their targets mapped the same way.
+func CDAWG(x string) *CDAWGraph {
+	sa, bwt, alpha := mappedBwt(x)
+	lcp := Lcp(x, sa)
+	ctab, otab := NewCTab(bwt, alpha.Size()), newOccTable(bwt, alpha.Size())
+	n := len(sa)
+
+	g := &CDAWGraph{text: x + "\x00"}
+	ids := map[[2]int]int{}
+	type work struct{ L, R, depth int }
+	queue := []work{}
+	node := func(L, R, depth int) int {
+		// Extend the interval backwards while it is not left-maximal.
+		for {
+			a := bwt[L]
+			if a == 0 || otab.RangeRank(a, L, R) != R-L {
+				break
+			}
+			L, R = extendInterval(a, L, R, ctab, otab)
+			depth++
+		}
+		key := [2]int{L, R}
+		if id, ok := ids[key]; ok {
+			return id
+		}
+		id := len(g.nodes)
+		ids[key] = id
+		g.nodes = append(g.nodes, cdawgNode{count: R - L, edges: map[byte]cdawgEdge{}})
+		queue = append(queue, work{L, R, depth})
+		return id
+	}
+
+	// The root interval contains the sentinel's row, so it is never
+	// extended and the source gets id 0.
+	node(0, n, 0)
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		id := ids[[2]int{w.L, w.R}]
+
+		// The children of the interval are split where the lcp drops to
+		// its depth.
+		for start := w.L; start < w.R; {
+			end, childDepth := start+1, n
+			for end < w.R && int(lcp[end]) > w.depth {
+				if int(lcp[end]) < childDepth {
+					childDepth = int(lcp[end])
+				}
+				end++
+			}
+			labelStart := int(sa[start]) + w.depth
+			var edge cdawgEdge
+			if end-start == 1 {
+				// A leaf: the label runs to the end, including the
+				// sentinel. The sink's id is set once all nodes exist.
+				edge = cdawgEdge{int32(labelStart), int32(len(g.text)), -1}
+			} else {
+				edge = cdawgEdge{int32(labelStart), int32(int(sa[start]) + childDepth), node(start, end, childDepth)}
+			}
+			g.nodes[id].edges[g.text[labelStart]] = edge
+			start = end
+		}
+	}
+
+	sink := len(g.nodes)
+	g.nodes = append(g.nodes, cdawgNode{count: 1})
+	for _, nd := range g.nodes {
+		for a, edge := range nd.edges {
+			if edge.target < 0 {
+				edge.target = sink
+				nd.edges[a] = edge
+			}
+		}
+	}
+	return g
+}
+
+// Len returns the number of nodes in the graph, including the source and
+// the sink.
+func (g *CDAWGraph) Len() int {
+	return len(g.nodes)
+}
+
+// Source returns the source node, which spells the empty string.
+func (g *CDAWGraph) Source() int {
+	return cdawgSource
+}
+
+// Sink returns the sink node, where the paths for the suffixes end.
+func (g *CDAWGraph) Sink() int {
+	return len(g.nodes) - 1
+}
+
+// Edge returns the label and target of the edge out of node whose label
+// starts with a, and false if there is no such edge.
+func (g *CDAWGraph) Edge(node int, a byte) (label string, target int, ok bool) {
+	edge, ok := g.nodes[node].edges[a]
+	if !ok {
+		return "", 0, false
+	}
+	return g.text[edge.start:edge.end], edge.target, true
+}
+
+// Occurrences returns the number of occurrences of the strings node
+// represents.
+func (g *CDAWGraph) Occurrences(node int) int {
+	return g.nodes[node].count
+}
+
+// Count returns the number of occurrences of p in the string, by spelling
+// p from the source. The empty pattern matches every suffix, including
+// the empty one, as with Index.Count.
+func (g *CDAWGraph) Count(p string) int {
+	return cdawgCount(g, p)
+}
+
+// CountBytes is Count for a pattern given as a byte slice.
+func (g *CDAWGraph) CountBytes(p []byte) int {
+	return cdawgCount(g, p)
+}
+
+func cdawgCount[P pattern](g *CDAWGraph, p P) int {
+	node := cdawgSource
+	for i := 0; i < len(p); {
+		label, target, ok := g.Edge(node, p[i])
+		if !ok {
+			return 0
+		}
+		m := len(label)
+		if len(p)-i < m {
+			m = len(p) - i
+		}
+		for k := 0; k < m; k++ {
+			if label[k] != p[i+k] {
+				return 0
+			}
+		}
+		i, node = i+m, target
+	}
+	return g.nodes[node].count
+}
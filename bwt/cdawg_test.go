@@ -0,0 +1,47 @@
+package bwt
+
+import "testing"
+
+func TestCDAWG(t *testing.T) {
+	rng := newRandomSeed(t)
+	inputs := []string{"", "a", "aaaa", "abab", "mississippi", "abcabcabc"}
+	for i := 0; i < 20; i++ {
+		inputs = append(inputs, randomStringN(rng.Intn(60), "acgt", rng))
+	}
+	for _, x := range inputs {
+		g, idx := CDAWG(x), BuildIndex(x)
+		patterns := []string{"", "x", x + "a"}
+		for i := 0; i < len(x); i++ {
+			for j := i + 1; j <= len(x) && j-i <= 8; j++ {
+				patterns = append(patterns, x[i:j])
+			}
+		}
+		for j := 0; j < 20; j++ {
+			patterns = append(patterns, randomStringN(1+rng.Intn(4), "acgt", rng))
+		}
+		for _, p := range patterns {
+			if count, expected := g.Count(p), idx.Count(p); count != expected {
+				t.Errorf("Expected %d occurrences of %q in %q, got %d", expected, p, x, count)
+			}
+		}
+		if g.Occurrences(g.Sink()) != 1 {
+			t.Errorf("Expected the sink to occur once, got %d", g.Occurrences(g.Sink()))
+		}
+	}
+}
+
+func TestCDAWGNodes(t *testing.T) {
+	// The maximal repeats of "abcabcabc" are "abc" and "abcabc", so the
+	// graph has those, the source and the sink.
+	g := CDAWG("abcabcabc")
+	if g.Len() != 4 {
+		t.Fatalf("Expected 4 nodes, got %d", g.Len())
+	}
+	label, target, ok := g.Edge(g.Source(), 'b')
+	if !ok || label != "bc" || g.Occurrences(target) != 3 {
+		t.Errorf("Expected the edge bc to the node for abc, got %q to %d", label, target)
+	}
+	if _, _, ok := g.Edge(g.Source(), 'x'); ok {
+		t.Error("Expected no edge for x")
+	}
+}
@@ -0,0 +1,187 @@
+package bwt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// saStateMagic identifies a prefix-doubling state written by SaveSAState.
+const saStateMagic = "SAS1"
+
+// SAState is the state of a prefix-doubling construction between two
+// iterations: the suffixes sorted by their first k characters, the ranks
+// of those prefixes, and the number of distinct ranks. The slices belong
+// to the running construction, so a state is only valid during the
+// checkpoint call it is passed to.
+type SAState struct {
+	sa, rank []int32
+	k, sigma int32
+}
+
+// Len returns the number of suffixes, the length of the text plus one.
+func (state *SAState) Len() int {
+	return len(state.sa)
+}
+
+// PrefixLen returns the length of the prefixes the suffixes are sorted by.
+func (state *SAState) PrefixLen() int {
+	return int(state.k)
+}
+
+// Sigma returns the number of distinct prefixes so far; the construction
+// is done when it reaches Len.
+func (state *SAState) Sigma() int {
+	return int(state.sigma)
+}
+
+// PrefixDoublingCheckpoint computes the suffix array like PrefixDoubling,
+// calling checkpoint with the state after each iteration that leaves the
+// construction unfinished, so the caller can save it with SaveSAState
+// and later continue with ResumeSAConstruction. An error from checkpoint
+// stops the construction and is returned. A nil checkpoint is not called.
+func PrefixDoublingCheckpoint(x string, checkpoint func(state *SAState) error) ([]int32, error) {
+	if len(x) >= math.MaxInt32 {
+		return nil, fmt.Errorf("text of %d characters is too long for 32-bit positions", len(x))
+	}
+	rank, _ := calcRank0[int32](x)
+	sa := make([]int32, len(rank))
+	for i := range sa {
+		sa[i] = int32(i)
+	}
+	return checkpointRounds(sa, rank, 1, checkpoint)
+}
+
+// checkpointRounds runs doublingRounds from the given state, calling
+// checkpoint after each iteration that leaves the construction unfinished.
+func checkpointRounds(sa, rank []int32, k int32, checkpoint func(state *SAState) error) ([]int32, error) {
+	n := int32(len(rank))
+	return doublingRounds(sa, rank, k, 4, 8, func(rank []int32, k, sigma int32) error {
+		if checkpoint == nil || sigma == n {
+			return nil
+		}
+		return checkpoint(&SAState{sa, rank, k, sigma})
+	})
+}
+
+// SaveSAState writes state to w. The format is the magic string "SAS1",
+// the number of suffixes n as a little-endian uint64, the prefix length
+// and sigma as little-endian uint32s, and then the n suffix-array entries
+// and the n ranks as little-endian int32s.
+func SaveSAState(w io.Writer, state *SAState) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(saStateMagic); err != nil {
+		return err
+	}
+	header := []uint64{uint64(len(state.sa))}
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, []uint32{uint32(state.k), uint32(state.sigma)}); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, state.sa); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, state.rank); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ResumeSAConstruction reads a state written by SaveSAState and continues
+// the prefix doubling from it, calling checkpoint like
+// PrefixDoublingCheckpoint, and returns the suffix array. The ranks hold
+// all the construction still needs from the text, so the text itself is
+// not read again. The entries are read in blocks, so a corrupt length
+// fails when the data runs out rather than allocating it up front. The
+// state must be one a construction can have reached: the suffix array a
+// permutation of [0, n), and the ranks numbering its runs of equal
+// prefixes 0 to sigma-1 in order.
+func ResumeSAConstruction(r io.Reader, checkpoint func(state *SAState) error) ([]int32, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(saStateMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != saStateMagic {
+		return nil, fmt.Errorf("not a suffix array construction state")
+	}
+	var n uint64
+	if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	var params [2]uint32
+	if err := binary.Read(br, binary.LittleEndian, &params); err != nil {
+		return nil, err
+	}
+	k, sigma := params[0], params[1]
+	if n == 0 || n > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid number of suffixes %d", n)
+	}
+	if k == 0 || k&(k-1) != 0 || k > math.MaxInt32/2 || uint64(sigma) > n {
+		return nil, fmt.Errorf("invalid prefix length %d or sigma %d for %d suffixes", k, sigma, n)
+	}
+
+	sa, err := readEntries(br, int(n))
+	if err != nil {
+		return nil, err
+	}
+	rank, err := readEntries(br, int(n))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSAState(sa, rank, int32(sigma)); err != nil {
+		return nil, err
+	}
+	return checkpointRounds(sa, rank, int32(k), checkpoint)
+}
+
+// checkSAState checks that sa is a permutation and that the ranks along
+// it start at 0 and grow by at most one from row to row, to sigma-1. The
+// entries are already known to be in [0, n).
+func checkSAState(sa, rank []int32, sigma int32) error {
+	seen := make([]bool, len(sa))
+	for _, j := range sa {
+		if seen[j] {
+			return fmt.Errorf("suffix %d appears twice", j)
+		}
+		seen[j] = true
+	}
+	prev := int32(-1)
+	for i, j := range sa {
+		if r := rank[j]; r != prev && r != prev+1 {
+			return fmt.Errorf("rank %d in row %d does not follow rank %d", r, i, prev)
+		}
+		prev = rank[j]
+	}
+	if prev+1 != sigma {
+		return fmt.Errorf("%d distinct ranks, expected sigma %d", prev+1, sigma)
+	}
+	return nil
+}
+
+// readEntries reads n int32s in [0, n) from r, a block at a time.
+func readEntries(r io.Reader, n int) ([]int32, error) {
+	const block = 1 << 16
+	entries := []int32{}
+	for len(entries) < n {
+		size := n - len(entries)
+		if size > block {
+			size = block
+		}
+		buf := make([]int32, size)
+		if err := binary.Read(r, binary.LittleEndian, buf); err != nil {
+			return nil, err
+		}
+		for _, v := range buf {
+			if v < 0 || int(v) >= n {
+				return nil, fmt.Errorf("entry %d out of range for %d suffixes", v, n)
+			}
+		}
+		entries = append(entries, buf...)
+	}
+	return entries, nil
+}
@@ -0,0 +1,101 @@
+package bwt
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResumeSAConstruction(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"mississippi", strings.Repeat("ab", 100), randomStringN(2000, "acgt", rng)} {
+		expected := PrefixDoubling(x)
+
+		// Save the first checkpoint and interrupt the construction there.
+		var checkpoints []*bytes.Buffer
+		interrupted := errors.New("interrupted")
+		sa, err := PrefixDoublingCheckpoint(x, func(state *SAState) error {
+			if state.Len() != len(x)+1 || state.Sigma() >= state.Len() || state.PrefixLen() < 2 {
+				t.Errorf("Unexpected state: %d suffixes, prefix length %d, sigma %d", state.Len(), state.PrefixLen(), state.Sigma())
+			}
+			var buf bytes.Buffer
+			if err := SaveSAState(&buf, state); err != nil {
+				t.Fatal(err)
+			}
+			checkpoints = append(checkpoints, &buf)
+			return interrupted
+		})
+		if err != interrupted || sa != nil {
+			t.Fatalf("Expected the construction to stop with the checkpoint's error, got %v", err)
+		}
+
+		// Resume from the first checkpoint, saving the later ones.
+		for len(checkpoints) > 0 {
+			buf := checkpoints[0]
+			checkpoints = checkpoints[1:]
+			data := buf.Bytes()
+			sa, err := ResumeSAConstruction(bytes.NewReader(data), func(state *SAState) error {
+				var next bytes.Buffer
+				if err := SaveSAState(&next, state); err != nil {
+					t.Fatal(err)
+				}
+				checkpoints = append(checkpoints, &next)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sa, expected) {
+				t.Fatalf("Expected the resumed construction to give %v, got %v", expected, sa)
+			}
+		}
+	}
+
+	if sa, err := PrefixDoublingCheckpoint("mississippi", nil); err != nil || !reflect.DeepEqual(sa, PrefixDoubling("mississippi")) {
+		t.Errorf("Expected an uninterrupted construction to give the suffix array, got %v (%v)", sa, err)
+	}
+}
+
+func TestResumeSAConstructionErrors(t *testing.T) {
+	var state bytes.Buffer
+	PrefixDoublingCheckpoint("mississippi", func(s *SAState) error {
+		if state.Len() == 0 {
+			return SaveSAState(&state, s)
+		}
+		return nil
+	})
+	data := state.Bytes()
+
+	corrupt := func(offset int, b byte) []byte {
+		c := append([]byte{}, data...)
+		c[offset] = b
+		return c
+	}
+	save := func(sa, rank []int32, k, sigma int32) []byte {
+		var buf bytes.Buffer
+		if err := SaveSAState(&buf, &SAState{sa, rank, k, sigma}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	zeros := make([]int32, 8)
+	for name, input := range map[string][]byte{
+		"permutation": save(zeros, zeros, 2, 1),
+		"stuck":       save([]int32{0, 1, 2, 3, 4, 5, 6, 7}, zeros, 4, 1), // ranks no text has
+		"ranks":       save([]int32{0, 1, 2, 3}, []int32{0, 2, 2, 3}, 2, 3),
+		"sigma":       save([]int32{0, 1, 2, 3}, []int32{0, 1, 1, 2}, 2, 2),
+		"magic":       corrupt(0, 'x'),
+		"truncated":   data[:len(data)-3],
+		"length":      corrupt(4+7, 0x7f), // over 32 bits
+		"short":       corrupt(4+3, 0x10), // far more suffixes than data
+		"k":           corrupt(12, 3),
+		"entry":       corrupt(20, 0xff),
+		"empty":       {},
+	} {
+		if _, err := ResumeSAConstruction(bytes.NewReader(input), nil); err == nil {
+			t.Errorf("Expected an error for a corrupt %s", name)
+		}
+	}
+}
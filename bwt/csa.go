@@ -0,0 +1,77 @@
+package bwt
+
+// CSA is a compressed suffix array: an FM-index for searching, with
+// samples of the suffix array for locating matches and samples of the
+// inverse suffix array for extracting substrings, so neither the text nor
+// the full suffix array is stored.
+//
+// The sample rate s sets the trade-off between space and time. The
+// suffix array is sampled at the rows whose suffix starts at a multiple
+// of s, and the inverse suffix array at the text positions that are
+// multiples of s, so the samples take O(n/s) space, and each located
+// position or extracted substring costs at most s extra LF steps.
+type CSA struct {
+	bwt   []byte
+	alpha *Alphabet
+	ctab  *CTab
+	otab  rankTable
+	*saSamples
+}
+
+// NewCSA builds the compressed suffix array for x with sample rate rate.
+func NewCSA(x string, rate int) *CSA {
+	sa, bwt, alpha := mappedBwt(x)
+	return &CSA{
+		bwt:       bwt,
+		alpha:     alpha,
+		ctab:      NewCTab(bwt, alpha.Size()),
+		otab:      newOccTable(bwt, alpha.Size()),
+		saSamples: newSASamples(sa, rate),
+	}
+}
+
+// lf returns the row of the suffix that starts one position before the
+// suffix in row i, and the character between them.
+func (csa *CSA) lf(i int) (int, byte) {
+	a := csa.bwt[i]
+	return csa.ctab.Rank(a) + csa.otab.Rank(a, i), a
+}
+
+// Count returns the number of occurrences of p in the text.
+func (csa *CSA) Count(p string) int {
+	L, R := backwardSearch(p, len(csa.bwt), csa.alpha, csa.ctab, csa.otab)
+	return R - L
+}
+
+// CountBytes is Count for a pattern given as a byte slice.
+func (csa *CSA) CountBytes(p []byte) int {
+	L, R := backwardSearch(p, len(csa.bwt), csa.alpha, csa.ctab, csa.otab)
+	return R - L
+}
+
+// Locate returns the positions where p occurs in the text, in suffix-array
+// order.
+func (csa *CSA) Locate(p string) []int32 {
+	return csaLocate(csa, p)
+}
+
+// LocateBytes is Locate for a pattern given as a byte slice.
+func (csa *CSA) LocateBytes(p []byte) []int32 {
+	return csaLocate(csa, p)
+}
+
+func csaLocate[P pattern](csa *CSA, p P) []int32 {
+	L, R := backwardSearch(p, len(csa.bwt), csa.alpha, csa.ctab, csa.otab)
+	return csa.positions(L, R, csa.lf)
+}
+
+// Len returns the length of the text.
+func (csa *CSA) Len() int {
+	return len(csa.bwt) - 1
+}
+
+// Extract returns the substring x[i:j] of the text.
+func (csa *CSA) Extract(i, j int) string {
+	y, _ := csa.alpha.Unmap(csa.extract(i, j, csa.Len(), csa.lf))
+	return y
+}
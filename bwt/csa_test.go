@@ -0,0 +1,50 @@
+package bwt
+
+import (
+	"testing"
+)
+
+func TestCSA(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(1+rng.Intn(100), "acgt", rng)
+		sa := PrefixDoubling(x)
+		ref := BuildIndex(x)
+
+		for _, rate := range []int{1, 3, 8, 200} {
+			csa := NewCSA(x, rate)
+			for j := 0; j < 10; j++ {
+				p := randomStringN(1+rng.Intn(3), "acgt", rng)
+				if csa.Count(p) != ref.Count(p) {
+					t.Errorf("Count(%q) = %d, expected %d", p, csa.Count(p), ref.Count(p))
+				}
+				located, expected := csa.Locate(p), ref.Locate(p)
+				if len(located) != len(expected) {
+					t.Fatalf("Locate(%q) = %v, expected %v", p, located, expected)
+				}
+				for k := range located {
+					if located[k] != expected[k] {
+						t.Fatalf("Locate(%q) = %v, expected %v", p, located, expected)
+					}
+				}
+			}
+
+			for row := range sa {
+				if pos := csa.position(row, csa.lf); pos != sa[row] {
+					t.Fatalf("Rate %d: row %d has position %d, expected %d", rate, row, pos, sa[row])
+				}
+			}
+
+			for j := 0; j < 10; j++ {
+				from := rng.Intn(len(x) + 1)
+				to := from + rng.Intn(len(x)-from+1)
+				if y := csa.Extract(from, to); y != x[from:to] {
+					t.Errorf("Rate %d: Extract(%d, %d) = %q, expected %q", rate, from, to, y, x[from:to])
+				}
+			}
+			if y := csa.Extract(0, len(x)); y != x {
+				t.Errorf("Rate %d: expected to extract the whole text", rate)
+			}
+		}
+	}
+}
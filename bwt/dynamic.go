@@ -0,0 +1,360 @@
+package bwt
+
+// fenwick is a Fenwick (binary indexed) tree over counts, supporting
+// point updates and prefix sums in O(log n) time.
+type fenwick []int
+
+// newFenwick builds the tree over counts in linear time.
+func newFenwick(counts []int) fenwick {
+	tree := make(fenwick, len(counts)+1)
+	for i, c := range counts {
+		tree[i+1] += c
+		if parent := (i + 1) + (i+1)&-(i+1); parent < len(tree) {
+			tree[parent] += tree[i+1]
+		}
+	}
+	return tree
+}
+
+// add adds delta to counts[i].
+func (tree fenwick) add(i, delta int) {
+	for i++; i < len(tree); i += i & -i {
+		tree[i] += delta
+	}
+}
+
+// sum returns the sum of counts[:i].
+func (tree fenwick) sum(i int) int {
+	s := 0
+	for ; i > 0; i -= i & -i {
+		s += tree[i]
+	}
+	return s
+}
+
+// find returns the index k of the count that holds position i, when the
+// counts are taken as the lengths of consecutive segments, and the offset
+// of i in that segment: the largest k with sum(k) <= i, and i - sum(k).
+// Counts of zero are empty segments, which are skipped, and a position
+// past the end gives the number of counts.
+func (tree fenwick) find(i int) (k, offset int) {
+	step := 1
+	for step*2 < len(tree) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if k+step < len(tree) && tree[k+step] <= i {
+			k += step
+			i -= tree[k]
+		}
+	}
+	return k, i
+}
+
+// dynamicBlockSize is the target number of symbols in a DynamicOTab block.
+// Blocks are split when they grow to twice this size.
+const dynamicBlockSize = 256
+
+// DynamicOTab is an O-table for a BWT that changes: symbols can be
+// inserted and deleted without rebuilding the table. The BWT is split
+// into blocks of at most 2*256 symbols, laid out in an array with free
+// slots between them, and Fenwick trees over the slots count the length
+// of each block and, for each symbol, its occurrences per block. Rank
+// finds the block that holds i in the tree of lengths, sums the symbol's
+// counts before it and scans the block, and Insert and Delete update a
+// single block and two trees, so all three take O(log n + 256) time.
+//
+// A block that fills up is split in two. The second half goes to the
+// next slot if it is free, and otherwise the blocks of the smallest
+// aligned window of slots around the block that has room are spread
+// evenly over it, as in a packed-memory array, moving only their counts
+// in the trees. The windows may be fuller the smaller they are, so a
+// split moves O(log^2 (n/256)) blocks amortized, and when the whole array
+// is half full, it is rebuilt twice as large.
+type DynamicOTab struct {
+	asize   int
+	n       int
+	blocks  [][]byte  // the blocks in order, with nil for the free slots
+	used    int       // the number of slots that hold a block
+	lengths fenwick   // the lengths of the blocks
+	trees   []fenwick // trees[a] counts a over the blocks
+}
+
+// NewDynamicOTab builds the dynamic O-table for bwt over an alphabet of
+// size asize.
+func NewDynamicOTab(bwt []byte, asize int) *DynamicOTab {
+	blocks := [][]byte{}
+	for i := 0; i < len(bwt); i += dynamicBlockSize {
+		end := i + dynamicBlockSize
+		if end > len(bwt) {
+			end = len(bwt)
+		}
+		blocks = append(blocks, append([]byte{}, bwt[i:end]...))
+	}
+	otab := &DynamicOTab{asize: asize, n: len(bwt)}
+	otab.rebuild(blocks)
+	return otab
+}
+
+// rebuild lays out blocks evenly in a new array, with at least three free
+// slots for every block, and recomputes the Fenwick trees.
+func (otab *DynamicOTab) rebuild(blocks [][]byte) {
+	size := 4
+	for size < 4*len(blocks) {
+		size *= 2
+	}
+	otab.blocks = make([][]byte, size)
+	otab.used = len(blocks)
+	lengths := make([]int, size)
+	counts := make([][]int, otab.asize)
+	for a := range counts {
+		counts[a] = make([]int, size)
+	}
+	for k, block := range blocks {
+		slot := k * size / len(blocks)
+		otab.blocks[slot] = block
+		lengths[slot] = len(block)
+		for _, a := range block {
+			counts[a][slot]++
+		}
+	}
+	otab.lengths = newFenwick(lengths)
+	otab.trees = make([]fenwick, otab.asize)
+	for a := range counts {
+		otab.trees[a] = newFenwick(counts[a])
+	}
+}
+
+// inOrder returns the blocks without the free slots.
+func (otab *DynamicOTab) inOrder() [][]byte {
+	blocks := make([][]byte, 0, otab.used)
+	for _, block := range otab.blocks {
+		if block != nil {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// addCounts adds sign times the length and symbol counts of block to the
+// trees at slot.
+func (otab *DynamicOTab) addCounts(slot int, block []byte, sign int) {
+	otab.lengths.add(slot, sign*len(block))
+	counts := make([]int, otab.asize)
+	for _, a := range block {
+		counts[a]++
+	}
+	for a, count := range counts {
+		if count > 0 {
+			otab.trees[a].add(slot, sign*count)
+		}
+	}
+}
+
+// find returns the slot of the block that holds position i and the offset
+// of i in it. Position n maps to the end of the last block. There must be
+// a block, if an empty one.
+func (otab *DynamicOTab) find(i int) (slot, offset int) {
+	if i < otab.n {
+		return otab.lengths.find(i)
+	}
+	if otab.n > 0 {
+		slot, offset = otab.lengths.find(otab.n - 1)
+		return slot, offset + 1
+	}
+	for slot, block := range otab.blocks {
+		if block != nil {
+			return slot, 0
+		}
+	}
+	return 0, 0
+}
+
+// Len returns the length of the BWT the table is for.
+func (otab *DynamicOTab) Len() int {
+	return otab.n
+}
+
+// Rank returns the number of occurrences of a in bwt[:i].
+func (otab *DynamicOTab) Rank(a byte, i int) int {
+	if i == 0 {
+		return 0
+	}
+	slot, offset := otab.find(i)
+	rank := otab.trees[a].sum(slot)
+	for _, c := range otab.blocks[slot][:offset] {
+		if c == a {
+			rank++
+		}
+	}
+	return rank
+}
+
+// Insert inserts the symbol a at position pos, so it is at bwt[pos]
+// afterwards. It panics if pos is not in [0, Len()] or a is not a symbol
+// of the table's alphabet.
+func (otab *DynamicOTab) Insert(pos int, a byte) {
+	if pos < 0 || pos > otab.n {
+		panic("bwt: insert position out of range")
+	}
+	if int(a) >= otab.asize {
+		panic("bwt: symbol out of range for the alphabet")
+	}
+	if otab.used == 0 {
+		otab.rebuild([][]byte{{}})
+	}
+	slot, offset := otab.find(pos)
+	block := append(otab.blocks[slot], 0)
+	copy(block[offset+1:], block[offset:])
+	block[offset] = a
+	otab.blocks[slot] = block
+	otab.n++
+	otab.lengths.add(slot, 1)
+	otab.trees[a].add(slot, 1)
+	if len(block) >= 2*dynamicBlockSize {
+		otab.split(slot)
+	}
+}
+
+// split splits the block at slot in two halves.
+func (otab *DynamicOTab) split(slot int) {
+	block := otab.blocks[slot]
+	half := len(block) / 2
+	first, second := block[:half:half], append([]byte{}, block[half:]...)
+	size := len(otab.blocks)
+	if slot+1 < size && otab.blocks[slot+1] == nil {
+		otab.blocks[slot], otab.blocks[slot+1] = first, second
+		otab.addCounts(slot, second, -1)
+		otab.addCounts(slot+1, second, 1)
+		otab.used++
+		return
+	}
+
+	// The window of width w may be 1 - level/(2*height) full, from
+	// almost full for the smallest windows to half full for the array.
+	height := 0
+	for 1<<height < size {
+		height++
+	}
+	for w, level := 2, 1; w <= size; w, level = 2*w, level+1 {
+		lo := slot &^ (w - 1)
+		blocks := [][]byte{}
+		for t := lo; t < lo+w; t++ {
+			if t == slot {
+				blocks = append(blocks, first, second)
+			} else if otab.blocks[t] != nil {
+				blocks = append(blocks, otab.blocks[t])
+			}
+		}
+		if 2*height*len(blocks) > (2*height-level)*w {
+			continue
+		}
+		otab.addCounts(slot, second, -1)
+		otab.blocks[slot] = first
+		for t := lo; t < lo+w; t++ {
+			if otab.blocks[t] != nil {
+				otab.addCounts(t, otab.blocks[t], -1)
+				otab.blocks[t] = nil
+			}
+		}
+		for k, b := range blocks {
+			t := lo + k*w/len(blocks)
+			otab.blocks[t] = b
+			otab.addCounts(t, b, 1)
+		}
+		otab.used++
+		return
+	}
+	blocks := [][]byte{}
+	for t, b := range otab.blocks {
+		if t == slot {
+			blocks = append(blocks, first, second)
+		} else if b != nil {
+			blocks = append(blocks, b)
+		}
+	}
+	otab.rebuild(blocks)
+}
+
+// Delete removes the symbol at position pos. It panics if pos is not in
+// [0, Len()).
+func (otab *DynamicOTab) Delete(pos int) {
+	if pos < 0 || pos >= otab.n {
+		panic("bwt: delete position out of range")
+	}
+	slot, offset := otab.find(pos)
+	block := otab.blocks[slot]
+	a := block[offset]
+	otab.blocks[slot] = append(block[:offset], block[offset+1:]...)
+	otab.n--
+	otab.lengths.add(slot, -1)
+	otab.trees[a].add(slot, -1)
+
+	if len(otab.blocks[slot]) > 0 {
+		return
+	}
+	otab.blocks[slot] = nil
+	otab.used--
+	// Shrink the array when it is mostly free, so it stays O(n/256).
+	if len(otab.blocks) > 4 && 8*otab.used < len(otab.blocks) {
+		otab.rebuild(otab.inOrder())
+	}
+}
+
+// OnlineBWT builds the Burrows-Wheeler transform of a text one character
+// at a time, with the text growing at its front: Push(c) turns the BWT of
+// x into the BWT of c+x. Prepending c adds a single suffix and leaves the
+// order of the others unchanged, so the sentinel at the row of x is
+// replaced by c, and a new sentinel is inserted at the row of c+x, found
+// by an LF step on a DynamicOTab over the raw bytes. Appending to the end
+// of the text would instead reorder the suffixes that are prefixes of
+// longer ones, so to index a stream in reading order, push it in reverse
+// or index its reverse and search for reversed patterns.
+//
+// Each Push takes the DynamicOTab's O(log n + 256) amortized time.
+type OnlineBWT struct {
+	otab     *DynamicOTab
+	counts   [256]int // counts[a] is the number of a's in the text
+	sentinel int      // the row of the whole text, where the BWT has the sentinel
+}
+
+// NewOnlineBWT returns the transform of the empty text, the lone sentinel.
+func NewOnlineBWT() *OnlineBWT {
+	return &OnlineBWT{otab: NewDynamicOTab([]byte{0}, 256)}
+}
+
+// Len returns the length of the transform, one more than the length of
+// the text.
+func (o *OnlineBWT) Len() int {
+	return o.otab.Len()
+}
+
+// Push prepends c to the text. It panics if c is the zero byte, which is
+// the sentinel.
+func (o *OnlineBWT) Push(c byte) {
+	if c == 0 {
+		panic("bwt: the zero byte is the sentinel")
+	}
+	// The new suffix is preceded by the sentinel suffix, the suffixes
+	// that start with a smaller character, and the suffixes c+y with y
+	// smaller than the old text.
+	row := 1 + o.otab.Rank(c, o.sentinel)
+	for a := 1; a < int(c); a++ {
+		row += o.counts[a]
+	}
+	o.otab.Delete(o.sentinel)
+	o.otab.Insert(o.sentinel, c)
+	o.otab.Insert(row, 0)
+	o.counts[c]++
+	o.sentinel = row
+}
+
+// BWT returns the transform of the text pushed so far, in the format of
+// Bwt, with a zero byte for the sentinel.
+func (o *OnlineBWT) BWT() []byte {
+	bwt := make([]byte, 0, o.Len())
+	for _, block := range o.otab.blocks {
+		bwt = append(bwt, block...)
+	}
+	return bwt
+}
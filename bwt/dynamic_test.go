@@ -0,0 +1,103 @@
+package bwt
+
+import "testing"
+
+func checkDynamicOTab(t *testing.T, bwt []byte, otab *DynamicOTab, asize int) {
+	t.Helper()
+	if otab.Len() != len(bwt) {
+		t.Fatalf("Expected length %d, got %d", len(bwt), otab.Len())
+	}
+	static := NewOTab(bwt, asize)
+	for a := 1; a < asize; a++ {
+		for i := 0; i <= len(bwt); i++ {
+			if rank, expected := otab.Rank(byte(a), i), static.Rank(byte(a), i); rank != expected {
+				t.Fatalf("Rank(%d, %d) = %d, expected %d", a, i, rank, expected)
+			}
+		}
+	}
+}
+
+func TestDynamicOTab(t *testing.T) {
+	rng := newRandomSeed(t)
+	const asize = 5
+	bwt := make([]byte, 300)
+	for i := range bwt {
+		bwt[i] = byte(rng.Intn(asize))
+	}
+	otab := NewDynamicOTab(bwt, asize)
+	checkDynamicOTab(t, bwt, otab, asize)
+
+	// Enough inserts to split blocks several times.
+	for k := 0; k < 1500; k++ {
+		pos, a := rng.Intn(len(bwt)+1), byte(rng.Intn(asize))
+		otab.Insert(pos, a)
+		bwt = append(bwt[:pos], append([]byte{a}, bwt[pos:]...)...)
+		if k%100 == 0 {
+			checkDynamicOTab(t, bwt, otab, asize)
+		}
+	}
+	checkDynamicOTab(t, bwt, otab, asize)
+
+	// Delete everything, emptying blocks along the way.
+	for len(bwt) > 0 {
+		pos := rng.Intn(len(bwt))
+		otab.Delete(pos)
+		bwt = append(bwt[:pos], bwt[pos+1:]...)
+		if len(bwt)%100 == 0 {
+			checkDynamicOTab(t, bwt, otab, asize)
+		}
+	}
+
+	// And the table still works from empty.
+	for k := 0; k < 50; k++ {
+		pos, a := rng.Intn(len(bwt)+1), byte(rng.Intn(asize))
+		otab.Insert(pos, a)
+		bwt = append(bwt[:pos], append([]byte{a}, bwt[pos:]...)...)
+	}
+	checkDynamicOTab(t, bwt, otab, asize)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a symbol outside the alphabet")
+		}
+	}()
+	otab.Insert(0, asize)
+}
+
+func TestDynamicOTabBWT(t *testing.T) {
+	// A dynamic table drops in for the static one in backward search.
+	x := "mississippi"
+	idx := BuildIndex(x)
+	otab := NewDynamicOTab(idx.bwt, idx.alpha.Size())
+	for _, p := range []string{"ssi", "i", "pp", "x", "mississippi"} {
+		L, R := backwardSearch(p, len(idx.bwt), idx.alpha, idx.ctab, otab)
+		if R-L != idx.Count(p) {
+			t.Errorf("Expected %d occurrences of %q, got %d", idx.Count(p), p, R-L)
+		}
+	}
+}
+
+func TestOnlineBWT(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "a", "mississippi", randomStringN(1500, "acgt", rng)} {
+		o := NewOnlineBWT()
+		for i := len(x) - 1; i >= 0; i-- {
+			o.Push(x[i])
+			if i%100 == 0 || len(x) < 20 {
+				if expected := Bwt(x[i:]); string(o.BWT()) != expected {
+					t.Fatalf("Expected BWT %q of %q, got %q", expected, x[i:], o.BWT())
+				}
+			}
+		}
+		if expected := Bwt(x); string(o.BWT()) != expected || o.Len() != len(x)+1 {
+			t.Errorf("Expected BWT %q of %q, got %q", expected, x, o.BWT())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for pushing the sentinel")
+		}
+	}()
+	NewOnlineBWT().Push(0)
+}
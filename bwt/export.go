@@ -0,0 +1,78 @@
+package bwt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// FormatPlainBWT is the plain-text BWT layout read by many FM-index and
+// BWT tools: the transform written in the text's own characters, with the
+// sentinel written as '$', followed by a newline. The tools sort '$'
+// before every other character, so the layout only fits texts whose
+// characters all sort after '$', as the letters and digits do.
+const FormatPlainBWT = "plain-bwt"
+
+// plainSentinel is the sentinel character of FormatPlainBWT.
+const plainSentinel = '$'
+
+// ExportCompatible writes the index's BWT to w in the named external
+// format, so it can be queried by another tool. The only supported format
+// is FormatPlainBWT. It is an error if the text has characters the
+// format cannot represent.
+func ExportCompatible(w io.Writer, index *Index, format string) error {
+	if format != FormatPlainBWT {
+		return fmt.Errorf("unknown index format %q", format)
+	}
+	letters := index.alpha.letters
+	if len(letters) > 1 && letters[1] <= plainSentinel {
+		return fmt.Errorf("character %q does not sort after the sentinel %q", letters[1], plainSentinel)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, s := range index.bwt {
+		a := letters[s]
+		if s == 0 {
+			a = plainSentinel
+		}
+		if err := bw.WriteByte(a); err != nil {
+			return err
+		}
+	}
+	if err := bw.WriteByte('\n'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ImportCompatible reads a BWT written in the named external format, by
+// ExportCompatible or another tool, and returns it mapped to its alphabet
+// like LoadBwt does.
+func ImportCompatible(r io.Reader, format string) (bwt []byte, alpha *Alphabet, err error) {
+	if format != FormatPlainBWT {
+		return nil, nil, fmt.Errorf("unknown index format %q", format)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if bytes.Count(data, []byte{plainSentinel}) != 1 {
+		return nil, nil, fmt.Errorf("expected exactly one sentinel %q in the BWT", plainSentinel)
+	}
+
+	var occurs [256]bool
+	for _, a := range data {
+		if a < plainSentinel {
+			return nil, nil, fmt.Errorf("character %q does not sort after the sentinel %q", a, plainSentinel)
+		}
+		occurs[a] = a != plainSentinel
+	}
+	alpha = alphabetFromOccurrences(&occurs)
+	bwt = make([]byte, len(data))
+	for i, a := range data {
+		bwt[i] = alpha.symbols[a] // the sentinel maps to 0
+	}
+	return bwt, alpha, nil
+}
@@ -0,0 +1,41 @@
+package bwt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExportCompatible(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "mississippi", randomStringN(100, "ACGT", rng)} {
+		idx := BuildIndex(x)
+
+		var buf bytes.Buffer
+		if err := ExportCompatible(&buf, idx, FormatPlainBWT); err != nil {
+			t.Fatal(err)
+		}
+		if x == "mississippi" && buf.String() != "ipssm$pissii\n" {
+			t.Errorf("Expected the BWT ipssm$pissii, got %q", buf.String())
+		}
+		bwt, alpha, err := ImportCompatible(&buf, FormatPlainBWT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(bwt, idx.BWT()) {
+			t.Errorf("Expected BWT %v, got %v", idx.BWT(), bwt)
+		}
+		if y, _ := alpha.Unmap(reverseBwt(bwt, alpha.Size())); y != x {
+			t.Errorf("Expected the imported BWT to reverse to %q, got %q", x, y)
+		}
+	}
+
+	if err := ExportCompatible(&bytes.Buffer{}, BuildIndex("a b"), FormatPlainBWT); err == nil {
+		t.Errorf("Expected an error for a character that sorts before the sentinel")
+	}
+	if err := ExportCompatible(&bytes.Buffer{}, BuildIndex("ab"), "bogus"); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+	if _, _, err := ImportCompatible(bytes.NewBufferString("ab$$\n"), FormatPlainBWT); err == nil {
+		t.Errorf("Expected an error for two sentinels")
+	}
+}
@@ -0,0 +1,306 @@
+package bwt
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+)
+
+// externalBlockSize is the number of suffixes BwtExternal sorts in memory
+// at a time. It is a variable so tests can use small blocks.
+var externalBlockSize int64 = 1 << 22
+
+// externalChunk is how many characters are read at a time when two
+// suffixes are compared on disk.
+const externalChunk = 256
+
+// suffixFile gives access to a text stored on disk for comparing its
+// suffixes. The text is terminated by an implicit sentinel.
+type suffixFile struct {
+	text       io.ReaderAt
+	n          int64
+	bufi, bufj [externalChunk]byte
+}
+
+// compare compares the suffixes at i and j, reading the text from disk.
+func (f *suffixFile) compare(i, j int64) (int, error) {
+	for i < f.n && j < f.n {
+		ni, err := f.read(f.bufi[:], i)
+		if err != nil {
+			return 0, err
+		}
+		nj, err := f.read(f.bufj[:], j)
+		if err != nil {
+			return 0, err
+		}
+		m := ni
+		if nj < m {
+			m = nj
+		}
+		if c := bytes.Compare(f.bufi[:m], f.bufj[:m]); c != 0 {
+			return c, nil
+		}
+		i, j = i+int64(m), j+int64(m)
+	}
+	// At least one suffix is down to the sentinel, which is smaller than
+	// any character.
+	switch {
+	case i == j:
+		return 0, nil
+	case i == f.n:
+		return -1, nil
+	case j == f.n:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// read reads the text from position i into buf, up to the end of the text.
+func (f *suffixFile) read(buf []byte, i int64) (int, error) {
+	if rest := f.n - i; rest < int64(len(buf)) {
+		buf = buf[:rest]
+	}
+	return f.text.ReadAt(buf, i)
+}
+
+// runEntry is a suffix in a sorted run, with its BWT character.
+type runEntry struct {
+	pos int64
+	bwt byte
+}
+
+const runEntrySize = 9
+
+func writeRunEntry(w io.Writer, e runEntry) error {
+	var buf [runEntrySize]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(e.pos))
+	buf[8] = e.bwt
+	_, err := w.Write(buf[:])
+	return err
+}
+
+func readRunEntry(r io.Reader) (runEntry, error) {
+	var buf [runEntrySize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return runEntry{}, err
+	}
+	return runEntry{int64(binary.LittleEndian.Uint64(buf[:8])), buf[8]}, nil
+}
+
+// sortBlock sorts the suffixes starting in [start, end) and writes them,
+// with their BWT characters, to a run file in dir. The block's text and
+// a block's worth of lookahead are read into memory, so most comparisons
+// never touch the disk.
+func sortBlock(f *suffixFile, start, end int64, dir string) (string, error) {
+	windowEnd := end + (end - start)
+	if windowEnd > f.n {
+		windowEnd = f.n
+	}
+	// The window also holds the character before the block, for the BWT.
+	windowStart := start
+	if start > 0 {
+		windowStart = start - 1
+	}
+	window := make([]byte, windowEnd-windowStart)
+	if _, err := f.text.ReadAt(window, windowStart); err != nil && err != io.EOF {
+		return "", err
+	}
+	at := func(i int64) []byte {
+		return window[i-windowStart:]
+	}
+
+	positions := make([]int64, end-start)
+	for i := range positions {
+		positions[i] = start + int64(i)
+	}
+
+	var sortErr error
+	sort.Slice(positions, func(a, b int) bool {
+		i, j := positions[a], positions[b]
+		wi, wj := at(i), at(j)
+		m := len(wi)
+		if len(wj) < m {
+			m = len(wj)
+		}
+		if c := bytes.Compare(wi[:m], wj[:m]); c != 0 {
+			return c < 0
+		}
+		if windowEnd == f.n {
+			// The window reaches the sentinel, so the shorter
+			// suffix is the smaller one.
+			return len(wi) < len(wj)
+		}
+		c, err := f.compare(i+int64(m), j+int64(m))
+		if err != nil && sortErr == nil {
+			sortErr = err
+		}
+		return c < 0
+	})
+	if sortErr != nil {
+		return "", sortErr
+	}
+
+	run, err := os.CreateTemp(dir, "bwt-run-*")
+	if err != nil {
+		return "", err
+	}
+	defer run.Close()
+	w := bufio.NewWriter(run)
+	for _, i := range positions {
+		e := runEntry{pos: i}
+		if i > 0 {
+			e.bwt = window[i-1-windowStart]
+		}
+		if err := writeRunEntry(w, e); err != nil {
+			return run.Name(), err
+		}
+	}
+	return run.Name(), w.Flush()
+}
+
+// runHead is the smallest remaining suffix of a run during the merge.
+type runHead struct {
+	entry runEntry
+	run   *bufio.Reader
+}
+
+// runHeap is a min-heap of run heads, ordered by their suffixes.
+type runHeap struct {
+	heads []runHead
+	file  *suffixFile
+	err   error
+}
+
+func (h *runHeap) Len() int { return len(h.heads) }
+func (h *runHeap) Less(a, b int) bool {
+	c, err := h.file.compare(h.heads[a].entry.pos, h.heads[b].entry.pos)
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+	return c < 0
+}
+func (h *runHeap) Swap(a, b int)      { h.heads[a], h.heads[b] = h.heads[b], h.heads[a] }
+func (h *runHeap) Push(x interface{}) { h.heads = append(h.heads, x.(runHead)) }
+func (h *runHeap) Pop() interface{} {
+	last := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return last
+}
+
+// tempFile is a file that is removed when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	err := f.File.Close()
+	if rmErr := os.Remove(f.Name()); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// BwtExternal computes the Burrows-Wheeler transform of the text read from
+// r, like Bwt, but without holding the text or its suffix array in memory.
+// The text is copied to a file in tmpDir, its suffixes are sorted in
+// blocks that each fit in memory and written to sorted runs on disk, and
+// the runs are then merged into the BWT, which is also written to disk.
+// The returned reader streams the BWT; closing it removes its file.
+//
+// Comparing suffixes from different blocks reads the text from disk, so
+// the construction is much slower than Bwt and is meant for texts that do
+// not fit in memory.
+func BwtExternal(r io.Reader, tmpDir string) (io.ReadCloser, error) {
+	text, err := os.CreateTemp(tmpDir, "bwt-text-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(text.Name())
+	defer text.Close()
+
+	n, err := io.Copy(text, bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	f := &suffixFile{text: text, n: n}
+
+	// Sort the blocks, including the sentinel suffix at n.
+	runNames := []string{}
+	defer func() {
+		for _, name := range runNames {
+			os.Remove(name)
+		}
+	}()
+	for start := int64(0); start <= n; start += externalBlockSize {
+		end := start + externalBlockSize
+		if end > n+1 {
+			end = n + 1
+		}
+		name, err := sortBlock(f, start, end, tmpDir)
+		if name != "" {
+			runNames = append(runNames, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Merge the runs into the BWT.
+	h := &runHeap{file: f}
+	for _, name := range runNames {
+		run, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer run.Close()
+		br := bufio.NewReader(run)
+		e, err := readRunEntry(br)
+		if err != nil {
+			return nil, err
+		}
+		h.heads = append(h.heads, runHead{e, br})
+	}
+	heap.Init(h)
+
+	out, err := os.CreateTemp(tmpDir, "bwt-out-*")
+	if err != nil {
+		return nil, err
+	}
+	result := tempFile{out}
+	fail := func(err error) (io.ReadCloser, error) {
+		result.Close()
+		return nil, err
+	}
+
+	w := bufio.NewWriter(out)
+	for h.Len() > 0 {
+		head := &h.heads[0]
+		if err := w.WriteByte(head.entry.bwt); err != nil {
+			return fail(err)
+		}
+		e, err := readRunEntry(head.run)
+		switch {
+		case err == io.EOF:
+			heap.Pop(h)
+		case err != nil:
+			return fail(err)
+		default:
+			head.entry = e
+			heap.Fix(h, 0)
+		}
+		if h.err != nil {
+			return fail(h.err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package bwt
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBwtExternal(t *testing.T) {
+	defer func(size int64) { externalBlockSize = size }(externalBlockSize)
+
+	rng := newRandomSeed(t)
+	inputs := []string{
+		"",
+		"mississippi",
+		randomStringN(5000, "acgt", rng),
+		strings.Repeat("acgtt", 1000), // long shared prefixes across blocks
+	}
+	for _, blockSize := range []int64{13, 997, 1 << 20} {
+		externalBlockSize = blockSize
+		for _, x := range inputs {
+			dir := t.TempDir()
+			r, err := BwtExternal(strings.NewReader(x), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			y, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if string(y) != Bwt(x) {
+				t.Errorf("Block size %d: external BWT of a length %d string differs from Bwt",
+					blockSize, len(x))
+			}
+			if files, _ := os.ReadDir(dir); len(files) != 0 {
+				t.Errorf("Expected all temporary files to be removed, found %d", len(files))
+			}
+		}
+	}
+}
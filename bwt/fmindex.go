@@ -0,0 +1,126 @@
+package bwt
+
+import "sort"
+
+// FMIndex bundles the suffix array, the BWT and the C- and O-tables of a
+// text, so they are always built together and over the same alphabet. The
+// BWT is mapped to the text's alphabet, so the tables only cover its
+// Size() symbols instead of all 256 possible bytes, and patterns are
+// mapped to it on the fly. The O-table is an OTab or, for a large
+// alphabet, a wavelet matrix, as for an Index. For an index with more queries, such as
+// approximate search, see Index.
+type FMIndex struct {
+	sa    []int32
+	bwt   []byte
+	alpha *Alphabet
+	ctab  *CTab
+	otab  rankTable
+}
+
+// NewFMIndex builds the FM-index for x.
+func NewFMIndex(x string) *FMIndex {
+	sa, bwt, alpha := mappedBwt(x)
+	return &FMIndex{
+		sa:    sa,
+		bwt:   bwt,
+		alpha: alpha,
+		ctab:  NewCTab(bwt, alpha.Size()),
+		otab:  newOccTable(bwt, alpha.Size()),
+	}
+}
+
+// Len returns the length of the text.
+func (fm *FMIndex) Len() int {
+	return len(fm.bwt) - 1
+}
+
+// AlphabetSize returns the size of the text's alphabet, including the
+// sentinel, which is the number of rows in the tables.
+func (fm *FMIndex) AlphabetSize() int {
+	return fm.alpha.Size()
+}
+
+// Count returns the number of occurrences of p in the text. The empty
+// pattern occurs in every row, len(x)+1 times.
+func (fm *FMIndex) Count(p string) int {
+	L, R := backwardSearch(p, len(fm.bwt), fm.alpha, fm.ctab, fm.otab)
+	return R - L
+}
+
+// CountBytes is Count for a pattern given as a byte slice.
+func (fm *FMIndex) CountBytes(p []byte) int {
+	L, R := backwardSearch(p, len(fm.bwt), fm.alpha, fm.ctab, fm.otab)
+	return R - L
+}
+
+// Locate returns the positions where p occurs in the text, in suffix-array
+// order, leaving out the sentinel's position like the package-level
+// Locate.
+func (fm *FMIndex) Locate(p string) []int {
+	return fmLocate(fm, p)
+}
+
+// LocateBytes is Locate for a pattern given as a byte slice.
+func (fm *FMIndex) LocateBytes(p []byte) []int {
+	return fmLocate(fm, p)
+}
+
+func fmLocate[P pattern](fm *FMIndex, p P) []int {
+	L, R := backwardSearch(p, len(fm.bwt), fm.alpha, fm.ctab, fm.otab)
+	positions := make([]int, 0, R-L)
+	for _, pos := range fm.sa[L:R] {
+		if int(pos) != fm.Len() {
+			positions = append(positions, int(pos))
+		}
+	}
+	return positions
+}
+
+// ApproxMatch returns the positions in the text where a string of the
+// same length as p starts that differs from p in at most k positions,
+// its Hamming distance, sorted. The search extends backward search into
+// a branch and bound that tries every symbol at every position of p,
+// spending one of the k mismatches when the symbol differs from p's, and
+// prunes a branch when its interval is empty or a mismatch is needed with
+// the budget spent. Each branch spells a different string, and a position
+// starts only one string of a given length, so the positions are
+// distinct. As with Locate, the empty pattern matches at every position
+// of the text but not at the sentinel's, and a negative k matches
+// nowhere.
+func ApproxMatch(p string, k int, idx *FMIndex) []int {
+	return approxMatch(p, k, idx)
+}
+
+// ApproxMatchBytes is ApproxMatch for a pattern given as a byte slice.
+func ApproxMatchBytes(p []byte, k int, idx *FMIndex) []int {
+	return approxMatch(p, k, idx)
+}
+
+func approxMatch[P pattern](p P, k int, idx *FMIndex) []int {
+	positions := []int{}
+	if k < 0 {
+		return positions
+	}
+	tableMismatchSearch(p, len(idx.bwt), idx.alpha, idx.ctab, idx.otab, k, func(L, R, d int) {
+		for _, pos := range idx.sa[L:R] {
+			if int(pos) != idx.Len() {
+				positions = append(positions, int(pos))
+			}
+		}
+	})
+	sort.Ints(positions)
+	return positions
+}
+
+// Reverse reverses the BWT with the index's own tables, which gives back
+// the text.
+func (fm *FMIndex) Reverse() string {
+	x := make([]byte, fm.Len())
+	i := 0 // the row of the rotation that starts with the sentinel
+	for j := len(x) - 1; j >= 0; j-- {
+		a := fm.bwt[i]
+		x[j] = fm.alpha.letters[a]
+		i = fm.ctab.Rank(a) + fm.otab.Rank(a, i)
+	}
+	return string(x)
+}
@@ -0,0 +1,83 @@
+package bwt
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFMIndex(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "mississippi", randomStringN(200, "acgt", rng)} {
+		fm := NewFMIndex(x)
+		if fm.Len() != len(x) {
+			t.Errorf("Expected length %d, got %d", len(x), fm.Len())
+		}
+		if size := NewAlphabet(x).Size(); fm.AlphabetSize() != size {
+			t.Errorf("Expected alphabet size %d, got %d", size, fm.AlphabetSize())
+		}
+		if y := fm.Reverse(); y != x {
+			t.Errorf("Expected Reverse to give %q, got %q", x, y)
+		}
+		if count := fm.Count(""); count != len(x)+1 {
+			t.Errorf("Expected the empty pattern %d times, got %d", len(x)+1, count)
+		}
+
+		for i := 0; i < 20; i++ {
+			p := randomStringN(1+rng.Intn(3), "acgtimps", rng)
+			expected := []int{}
+			for _, pos := range naiveOccurrences(x, p) {
+				expected = append(expected, int(pos))
+			}
+			if count := fm.Count(p); count != len(expected) {
+				t.Errorf("Expected Count(%q) = %d in %q, got %d", p, len(expected), x, count)
+			}
+			if count := fm.CountBytes([]byte(p)); count != len(expected) {
+				t.Errorf("Expected CountBytes(%q) = %d in %q, got %d", p, len(expected), x, count)
+			}
+			for _, positions := range [][]int{fm.Locate(p), fm.LocateBytes([]byte(p))} {
+				sort.Ints(positions)
+				if !reflect.DeepEqual(positions, expected) {
+					t.Errorf("Expected %q at %v in %q, got %v", p, expected, x, positions)
+				}
+			}
+		}
+	}
+}
+
+func TestApproxMatch(t *testing.T) {
+	naive := func(x, p string, k int) []int {
+		positions := []int{}
+		for pos := 0; pos+len(p) <= len(x) && pos < len(x); pos++ {
+			if hamming(p, x[pos:pos+len(p)]) <= k {
+				positions = append(positions, pos)
+			}
+		}
+		return positions
+	}
+
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := randomStringN(rng.Intn(60), "acgt", rng)
+		fm := NewFMIndex(x)
+		for j := 0; j < 10; j++ {
+			// Empty patterns and negative budgets too.
+			p, k := randomStringN(rng.Intn(6), "acgtx", rng), rng.Intn(4)-1
+			expected := naive(x, p, k)
+			if positions := ApproxMatch(p, k, fm); !reflect.DeepEqual(positions, expected) {
+				t.Errorf("Expected %q with %d mismatches at %v in %q, got %v", p, k, expected, x, positions)
+			}
+			if positions := ApproxMatchBytes([]byte(p), k, fm); !reflect.DeepEqual(positions, expected) {
+				t.Errorf("Expected %q as bytes with %d mismatches at %v in %q, got %v", p, k, expected, x, positions)
+			}
+		}
+	}
+
+	fm := NewFMIndex("acgt")
+	if positions, expected := ApproxMatch("", 1, fm), fm.Locate(""); !reflect.DeepEqual(positions, expected) {
+		t.Errorf("Expected the empty pattern at %v like Locate, got %v", expected, positions)
+	}
+	if positions := ApproxMatch("", -1, fm); len(positions) != 0 {
+		t.Errorf("Expected no matches with a negative budget, got %v", positions)
+	}
+}
@@ -0,0 +1,223 @@
+package bwt
+
+import (
+	"sort"
+	"strings"
+)
+
+// GeneralizedIndex is an FM-index over a collection of documents. The
+// documents are concatenated, each followed by a separator byte that
+// occurs in none of them, and the document array records which document
+// the suffix in each row of the suffix array starts in.
+type GeneralizedIndex struct {
+	*Index
+	sep    byte
+	starts []int32 // starts[d] is where document d starts in the text
+	docs   []int32 // docs[i] is the document of the suffix in row i
+}
+
+// BuildGeneralizedIndex builds the index for docs. The separator is the
+// smallest non-zero byte that occurs in none of the documents. The
+// separator after a document belongs to it, and the sentinel to no
+// document, so its row in the document array is -1.
+func BuildGeneralizedIndex(docs []string) *GeneralizedIndex {
+	text, sep, starts := concatDocs(docs)
+	index := BuildIndex(text)
+
+	g := &GeneralizedIndex{Index: index, sep: sep, starts: starts, docs: make([]int32, len(index.sa))}
+	for row, pos := range index.sa {
+		if int(pos) == index.Len() {
+			g.docs[row] = -1
+		} else {
+			g.docs[row] = int32(g.document(pos))
+		}
+	}
+	return g
+}
+
+// concatDocs concatenates docs, each followed by the smallest non-zero
+// byte that occurs in none of them, and returns the text, the separator
+// and where each document starts in the text.
+func concatDocs(docs []string) (text string, sep byte, starts []int32) {
+	var occurs [256]bool
+	for _, doc := range docs {
+		for i := 0; i < len(doc); i++ {
+			occurs[doc[i]] = true
+		}
+	}
+	sep = 1
+	for occurs[sep] {
+		if sep == 255 {
+			panic("bwt: the documents leave no byte for the separator")
+		}
+		sep++
+	}
+
+	var b strings.Builder
+	starts = make([]int32, len(docs))
+	for d, doc := range docs {
+		starts[d] = int32(b.Len())
+		b.WriteString(doc)
+		b.WriteByte(sep)
+	}
+	return b.String(), sep, starts
+}
+
+// document returns the document that text position pos is in.
+func (g *GeneralizedIndex) document(pos int32) int {
+	return sort.Search(len(g.starts), func(d int) bool { return g.starts[d] > pos }) - 1
+}
+
+// NumDocs returns the number of documents in the index.
+func (g *GeneralizedIndex) NumDocs() int {
+	return len(g.starts)
+}
+
+// Document returns the document that position pos in the concatenated
+// text is in and the offset of pos in it.
+func (g *GeneralizedIndex) Document(pos int32) (doc int, offset int32) {
+	doc = g.document(pos)
+	return doc, pos - g.starts[doc]
+}
+
+// LocateInDocs returns the occurrences of p that start in the allowed
+// documents, sorted by position. The positions are in the concatenated
+// text, so Document maps them to documents and offsets. Rows are filtered
+// by the document array before they are resolved to positions, so rows in
+// other documents cost nothing beyond the check.
+func LocateInDocs(index *GeneralizedIndex, p string, allowed map[int]bool) []Match {
+	return locateInDocs(index, p, allowed)
+}
+
+// LocateInDocsBytes is LocateInDocs for a pattern given as a byte slice.
+func LocateInDocsBytes(index *GeneralizedIndex, p []byte, allowed map[int]bool) []Match {
+	return locateInDocs(index, p, allowed)
+}
+
+func locateInDocs[P pattern](index *GeneralizedIndex, p P, allowed map[int]bool) []Match {
+	matches := []Match{}
+	L, R := search(index.Index, p)
+	for i := L; i < R; i++ {
+		if d := index.docs[i]; d >= 0 && allowed[int(d)] {
+			pos := index.position(i)
+			matches = append(matches, Match{Pos: pos, End: pos + int32(len(p))})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Pos < matches[j].Pos })
+	return matches
+}
+
+// LocateWithinDocs returns the occurrences of p that lie entirely inside
+// one document, sorted by position. Only a pattern that contains the
+// separator matches across the boundary between two documents, or into
+// the separator after the last one, and since no document contains the
+// separator, such a pattern has no occurrences inside one. It is rejected
+// before the search, so every row of the other patterns' intervals that
+// starts in a document holds an occurrence, and the rows are checked
+// against the document array before they are resolved to positions.
+func LocateWithinDocs(index *GeneralizedIndex, p string) []Match {
+	return locateWithinDocs(index, p)
+}
+
+// LocateWithinDocsBytes is LocateWithinDocs for a pattern given as a byte
+// slice.
+func LocateWithinDocsBytes(index *GeneralizedIndex, p []byte) []Match {
+	return locateWithinDocs(index, p)
+}
+
+func locateWithinDocs[P pattern](index *GeneralizedIndex, p P) []Match {
+	matches := []Match{}
+	for i := 0; i < len(p); i++ {
+		if p[i] == index.sep {
+			return matches
+		}
+	}
+	L, R := search(index.Index, p)
+	for i := L; i < R; i++ {
+		if index.docs[i] >= 0 {
+			pos := index.position(i)
+			matches = append(matches, Match{Pos: pos, End: pos + int32(len(p))})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Pos < matches[j].Pos })
+	return matches
+}
+
+// LongestCommonSubstring returns a longest string that occurs in all of
+// strs, using the generalized suffix array and lcp array of their
+// concatenation. The lcp values are capped at the end of each string, so
+// no common prefix runs across a separator. A window slides over the
+// suffix array, shrunk from the left as long as it still holds a suffix
+// of every string, and the minimum lcp inside each such window, kept in a
+// monotone queue, is the length of a substring common to all of them. It
+// runs in O(n log n) time, dominated by building the suffix array. If
+// several common substrings are longest, one of them is returned.
+func LongestCommonSubstring(strs []string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+	text, _, starts := concatDocs(strs)
+	sa := PrefixDoubling(text)
+	lcp := Lcp(text, sa)
+
+	// doc[i] is the string the suffix in row i starts in, and -1 for the
+	// separators and the sentinel, whose suffixes match nothing.
+	doc := make([]int, len(sa))
+	end := func(d int) int32 { return starts[d] + int32(len(strs[d])) }
+	for i, pos := range sa {
+		d := sort.Search(len(starts), func(d int) bool { return starts[d] > pos }) - 1
+		if d < 0 || pos >= end(d) {
+			d = -1
+		}
+		doc[i] = d
+	}
+	for i := 1; i < len(sa); i++ {
+		for _, j := range [2]int{i - 1, i} {
+			if doc[j] < 0 {
+				lcp[i] = 0
+			} else if rest := end(doc[j]) - sa[j]; lcp[i] > rest {
+				lcp[i] = rest
+			}
+		}
+	}
+
+	counts := make([]int, len(strs))
+	covered := 0
+	queue := []int{} // rows in (lo, hi] with increasing lcp values
+	bestLen, bestPos := int32(0), int32(0)
+	lo := 0
+	for hi := 0; hi < len(sa); hi++ {
+		if hi > lo {
+			for len(queue) > 0 && lcp[queue[len(queue)-1]] >= lcp[hi] {
+				queue = queue[:len(queue)-1]
+			}
+			queue = append(queue, hi)
+		}
+		if d := doc[hi]; d >= 0 {
+			if counts[d] == 0 {
+				covered++
+			}
+			counts[d]++
+		}
+		for covered == len(strs) {
+			if len(strs) == 1 {
+				// A single string is its own longest common substring.
+				return strs[0]
+			}
+			if len(queue) > 0 && lcp[queue[0]] > bestLen {
+				bestLen, bestPos = lcp[queue[0]], sa[hi]
+			}
+			if d := doc[lo]; d >= 0 {
+				counts[d]--
+				if counts[d] == 0 {
+					covered--
+				}
+			}
+			lo++
+			for len(queue) > 0 && queue[0] <= lo {
+				queue = queue[1:]
+			}
+		}
+	}
+	return text[bestPos : bestPos+bestLen]
+}
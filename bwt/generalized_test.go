@@ -0,0 +1,155 @@
+package bwt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildGeneralizedIndex(t *testing.T) {
+	docs := []string{"acgt", "", "ggg", "ta"}
+	g := BuildGeneralizedIndex(docs)
+	if g.NumDocs() != len(docs) {
+		t.Fatalf("Expected %d documents, got %d", len(docs), g.NumDocs())
+	}
+	pos := int32(0)
+	for d, doc := range docs {
+		for offset := 0; offset <= len(doc); offset++ { // including the separator
+			if gotDoc, gotOffset := g.Document(pos); gotDoc != d || gotOffset != int32(offset) {
+				t.Errorf("Expected position %d in document %d at %d, got %d at %d", pos, d, offset, gotDoc, gotOffset)
+			}
+			pos++
+		}
+	}
+	if count := g.Count("gt"); count != 1 {
+		t.Errorf("Expected one occurrence of gt, got %d", count)
+	}
+}
+
+func TestLocateInDocs(t *testing.T) {
+	rng := newRandomSeed(t)
+	docs := make([]string, 10)
+	for d := range docs {
+		docs[d] = randomStringN(rng.Intn(40), "acgt", rng)
+	}
+	g := BuildGeneralizedIndex(docs)
+	for j := 0; j < 20; j++ {
+		p := randomStringN(1+rng.Intn(3), "acgt", rng)
+		allowed := map[int]bool{}
+		for d := range docs {
+			if rng.Intn(2) == 0 {
+				allowed[d] = true
+			}
+		}
+
+		// Locate everywhere, then filter by document.
+		expected := []Match{}
+		for _, pos := range sortedLocate(g.Index, p) {
+			if d, _ := g.Document(pos); allowed[d] {
+				expected = append(expected, Match{Pos: pos, End: pos + int32(len(p))})
+			}
+		}
+		if matches := LocateInDocs(g, p, allowed); !reflect.DeepEqual(matches, expected) {
+			t.Errorf("LocateInDocs(%q, %v) = %v, expected %v", p, allowed, matches, expected)
+		}
+	}
+
+	if matches := LocateInDocs(g, "a", map[int]bool{}); len(matches) != 0 {
+		t.Errorf("Expected no matches with no allowed documents, got %v", matches)
+	}
+}
+
+func TestLocateWithinDocs(t *testing.T) {
+	g := BuildGeneralizedIndex([]string{"acgta", "gtacg", "ta"})
+	sep := string(g.sep)
+	for _, test := range []struct {
+		p        string
+		expected []Match
+	}{
+		{"ta", []Match{{Pos: 3, End: 5}, {Pos: 7, End: 9}, {Pos: 12, End: 14}}},
+		{"cg", []Match{{Pos: 1, End: 3}, {Pos: 9, End: 11}}},
+		{"a" + sep + "g", []Match{}}, // across the first boundary
+		{"g" + sep + "t", []Match{}}, // across the second boundary
+		{"a" + sep, []Match{}},       // into a separator
+		{"x", []Match{}},
+	} {
+		if matches := LocateWithinDocs(g, test.p); !reflect.DeepEqual(matches, test.expected) {
+			t.Errorf("Expected %q within documents at %v, got %v", test.p, test.expected, matches)
+		}
+		if matches := LocateWithinDocsBytes(g, []byte(test.p)); !reflect.DeepEqual(matches, test.expected) {
+			t.Errorf("Expected %q as bytes within documents at %v, got %v", test.p, test.expected, matches)
+		}
+	}
+	// The straddling pattern does occur in the concatenated text.
+	if count := g.Count("a" + sep + "g"); count != 1 {
+		t.Errorf("Expected one occurrence across the boundary, got %d", count)
+	}
+
+	rng := newRandomSeed(t)
+	docs := make([]string, 10)
+	for d := range docs {
+		docs[d] = randomStringN(rng.Intn(20), "ab", rng)
+	}
+	g = BuildGeneralizedIndex(docs)
+	for j := 0; j < 20; j++ {
+		p := randomStringN(1+rng.Intn(3), "ab", rng)
+		expected := []Match{}
+		for d, doc := range docs {
+			for _, off := range naiveOccurrences(doc, p) {
+				pos := g.starts[d] + off
+				expected = append(expected, Match{Pos: pos, End: pos + int32(len(p))})
+			}
+		}
+		if matches := LocateWithinDocs(g, p); !reflect.DeepEqual(matches, expected) {
+			t.Errorf("LocateWithinDocs(%q) = %v, expected %v", p, matches, expected)
+		}
+	}
+}
+
+func TestLongestCommonSubstring(t *testing.T) {
+	naive := func(strs []string) int {
+		best := 0
+		for i := 0; i < len(strs[0]); i++ {
+			for j := i + best + 1; j <= len(strs[0]); j++ {
+				w, all := strs[0][i:j], true
+				for _, s := range strs[1:] {
+					all = all && strings.Contains(s, w)
+				}
+				if all {
+					best = j - i
+				}
+			}
+		}
+		return best
+	}
+	check := func(strs []string) {
+		t.Helper()
+		lcs := LongestCommonSubstring(strs)
+		for _, s := range strs {
+			if !strings.Contains(s, lcs) {
+				t.Errorf("Expected %q to occur in all of %q", lcs, strs)
+			}
+		}
+		if expected := naive(strs); len(lcs) != expected {
+			t.Errorf("Expected a common substring of %q of length %d, got %q", strs, expected, lcs)
+		}
+	}
+
+	check([]string{"xabcy", "zabcw", "abcab"})
+	check([]string{"banana", "ananas", "canal"})
+	check([]string{"abab", "abab"})
+	check([]string{"only"})
+	check([]string{"abc", ""})
+	check([]string{"abc", "def"})
+	rng := newRandomSeed(t)
+	for i := 0; i < 50; i++ {
+		strs := make([]string, 1+rng.Intn(4))
+		for j := range strs {
+			strs[j] = randomStringN(rng.Intn(20), "ab", rng)
+		}
+		check(strs)
+	}
+	if lcs := LongestCommonSubstring(nil); lcs != "" {
+		t.Errorf("Expected nothing in common between no strings, got %q", lcs)
+	}
+}
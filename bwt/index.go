@@ -0,0 +1,415 @@
+package bwt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// rankTable is the rank query an O-table answers.
+type rankTable interface {
+	Rank(a byte, i int) int
+}
+
+// occTable is the O-table of an Index: an OTab, or for large alphabets a
+// wavelet matrix over the BWT.
+type occTable interface {
+	rankTable
+	RangeRank(a byte, lo, hi int) int
+	SizeBytes() int
+}
+
+// largeAlphabet is the number of distinct characters above which an Index
+// answers rank queries from a wavelet matrix instead of an OTab, whose
+// sigma*n ints would take gigabytes for long texts.
+const largeAlphabet = 64
+
+// newOccTable builds the O-table for bwt over an alphabet of size asize,
+// picking the backend by the size of the alphabet.
+func newOccTable(bwt []byte, asize int) occTable {
+	if asize-1 > largeAlphabet {
+		return newWaveletOTab(bwt)
+	}
+	return NewOTab(bwt, asize)
+}
+
+// extendInterval maps the suffix-array interval [L, R) of the suffixes
+// that start with w to the interval for aw, where a is a mapped symbol.
+func extendInterval(a byte, L, R int, ctab *CTab, otab rankTable) (int, int) {
+	return ctab.Rank(a) + otab.Rank(a, L), ctab.Rank(a) + otab.Rank(a, R)
+}
+
+// pattern is the types search patterns can be given as. Every search
+// entry point takes a string and has a Bytes sibling for byte slices, and
+// both share a generic implementation, so neither is converted.
+type pattern interface {
+	~string | ~[]byte
+}
+
+// backwardSearch finds the suffix-array interval [L, R) of the suffixes
+// that have p as a prefix, in a BWT of length n over the alphabet alpha.
+// Characters outside the alphabet, and patterns longer than the text, give
+// an empty interval.
+func backwardSearch[P pattern](p P, n int, alpha *Alphabet, ctab *CTab, otab rankTable) (L, R int) {
+	if len(p) > n-1 {
+		return 0, 0
+	}
+	L, R = 0, n
+	for i := len(p) - 1; i >= 0 && L < R; i-- {
+		a, ok := alpha.Map(p[i])
+		if !ok {
+			return 0, 0
+		}
+		L, R = extendInterval(a, L, R, ctab, otab)
+	}
+	return L, R
+}
+
+// mappedBwt computes the suffix array of x and the BWT of x mapped to its
+// alphabet. Since the mapping preserves the order of the characters, the
+// suffix array of x is also the suffix array of the mapped string.
+func mappedBwt(x string) (sa []int32, bwt []byte, alpha *Alphabet) {
+	alpha = NewAlphabet(x)
+	y, _ := alpha.MapString(x) // cannot fail; alpha has all of x's characters
+	sa = PrefixDoubling(x)
+	return sa, bwtFromSA(string(y), sa), alpha
+}
+
+// Index is an FM-index for a string x. It holds x and its suffix array,
+// and the BWT, over x's alphabet, with the C- and O-tables used in
+// backward search. A compact index, from BuildIndexCompact, holds samples
+// of the suffix array instead of x and the full suffix array.
+type Index struct {
+	text    string
+	sa      []int32
+	samples *saSamples // nil unless the index is compact
+	bwt     []byte
+	alpha   *Alphabet
+	ctab    *CTab
+	otab    occTable
+
+	doubleStrand bool  // set by BuildDoubleStrandIndex
+	lfArray      []int // set by UseLFArray
+
+	saTreeOnce sync.Once
+	saTree     *waveletMatrix // the suffix array, built by RangeCount
+
+	intervalsOnce sync.Once
+	intervals     *lcpIntervals // built by MatchingStatistics
+}
+
+// BuildIndex builds the FM-index for x. If x has more than 64 distinct
+// characters, the O-table is replaced by a wavelet matrix over the BWT,
+// which takes O(n log sigma) bits instead of O(sigma n) ints, at the cost
+// of O(log sigma) time per rank query.
+func BuildIndex(x string) *Index {
+	sa, bwt, alpha := mappedBwt(x)
+	return &Index{
+		text:  x,
+		sa:    sa,
+		bwt:   bwt,
+		alpha: alpha,
+		ctab:  NewCTab(bwt, alpha.Size()),
+		otab:  newOccTable(bwt, alpha.Size()),
+	}
+}
+
+// BuildIndexes builds the FM-indexes of seqs over one shared alphabet, so
+// all their BWTs use the same symbols and can be compared with each other,
+// and the alphabet is only built once. If alpha is nil, the alphabet of
+// all the characters in seqs is used. It is an error if a sequence has a
+// character that is not in alpha.
+func BuildIndexes(seqs []string, alpha *Alphabet) ([]*Index, error) {
+	if alpha == nil {
+		var occurs [256]bool
+		for _, x := range seqs {
+			for i := 0; i < len(x); i++ {
+				occurs[x[i]] = true
+			}
+		}
+		alpha = alphabetFromOccurrences(&occurs)
+	}
+	indexes := make([]*Index, len(seqs))
+	for k, x := range seqs {
+		y, err := alpha.MapString(x)
+		if err != nil {
+			return nil, fmt.Errorf("sequence %d: %w", k, err)
+		}
+		// The alphabet preserves the order of the characters, so the
+		// suffix array of x is that of y.
+		sa := PrefixDoubling(x)
+		bwt := bwtFromSA(string(y), sa)
+		indexes[k] = &Index{
+			text:  x,
+			sa:    sa,
+			bwt:   bwt,
+			alpha: alpha,
+			ctab:  NewCTab(bwt, alpha.Size()),
+			otab:  newOccTable(bwt, alpha.Size()),
+		}
+	}
+	return indexes, nil
+}
+
+// compactSampleRate is the suffix-array sample rate of a compact index.
+const compactSampleRate = 32
+
+// BuildIndexCompact builds the FM-index for x like BuildIndex, but keeps
+// only the BWT, the tables and samples of the suffix array, not x or its
+// full suffix array. For a text of length n over sigma distinct
+// characters, the resident payload, as reported by SizeBytes, is
+//
+//   - the BWT: n+1 bytes,
+//   - the C-table: sigma+1 ints,
+//   - the O-table: sigma*(n+1) ints,
+//   - the sampled rows: a bit vector of w = ceil((n+1)/64) 64-bit words,
+//     with w+1 ints of rank directory and a select directory of three
+//     ints per 512 sampled rows and two bytes per 16, plus the positions
+//     of the sampled rows in sparse stretches,
+//   - the samples: 2*(n/32+1) int32s for the suffix and inverse suffix
+//     array samples.
+//
+// Searching is as fast as with BuildIndex, but each located position costs
+// up to 32 LF steps, and extracting x[i:j] costs j-i+32 steps.
+func BuildIndexCompact(x string) *Index {
+	sa, bwt, alpha := mappedBwt(x)
+	return &Index{
+		samples: newSASamples(sa, compactSampleRate),
+		bwt:     bwt,
+		alpha:   alpha,
+		ctab:    NewCTab(bwt, alpha.Size()),
+		otab:    newOccTable(bwt, alpha.Size()),
+	}
+}
+
+// Len returns the length of the indexed text, without the sentinel.
+func (idx *Index) Len() int {
+	return len(idx.bwt) - 1
+}
+
+// lf returns the row of the suffix one position before the suffix in row
+// i, and the mapped symbol between them.
+func (idx *Index) lf(i int) (int, byte) {
+	a := idx.bwt[i]
+	if idx.lfArray != nil {
+		return idx.lfArray[i], a
+	}
+	return idx.ctab.Rank(a) + idx.otab.Rank(a, i), a
+}
+
+// position returns the suffix-array value of row i.
+func (idx *Index) position(i int) int32 {
+	if idx.samples != nil {
+		return idx.samples.position(i, idx.lf)
+	}
+	return idx.sa[i]
+}
+
+// positions returns the suffix-array values of the rows [L, R).
+func (idx *Index) positions(L, R int) []int32 {
+	if idx.samples != nil {
+		return idx.samples.positions(L, R, idx.lf)
+	}
+	positions := make([]int32, R-L)
+	for i := range positions {
+		positions[i] = idx.position(L + i)
+	}
+	return positions
+}
+
+// BWT returns the index's BWT, mapped to the alphabet returned by Alphabet.
+// The slice is shared with the index and must not be modified.
+func (idx *Index) BWT() []byte {
+	return idx.bwt
+}
+
+// Alphabet returns the alphabet the index's BWT is mapped to.
+func (idx *Index) Alphabet() *Alphabet {
+	return idx.alpha
+}
+
+// search is Index.Search for either kind of pattern.
+func search[P pattern](idx *Index, p P) (L, R int) {
+	return backwardSearch(p, len(idx.bwt), idx.alpha, idx.ctab, idx.otab)
+}
+
+// Search returns the suffix-array interval [L, R) of the suffixes that
+// have p as a prefix. The interval is empty if p does not occur in x.
+func (idx *Index) Search(p string) (L, R int) {
+	return search(idx, p)
+}
+
+// SearchBytes is Search for a pattern given as a byte slice.
+func (idx *Index) SearchBytes(p []byte) (L, R int) {
+	return search(idx, p)
+}
+
+// Count returns the number of occurrences of p in x.
+func (idx *Index) Count(p string) int {
+	L, R := search(idx, p)
+	return R - L
+}
+
+// CountBytes is Count for a pattern given as a byte slice.
+func (idx *Index) CountBytes(p []byte) int {
+	L, R := search(idx, p)
+	return R - L
+}
+
+// Extract returns the substring x[i:j] of the indexed text.
+func (idx *Index) Extract(i, j int) string {
+	if idx.samples != nil {
+		y, _ := idx.alpha.Unmap(idx.samples.extract(i, j, idx.Len(), idx.lf))
+		return y
+	}
+	return idx.text[i:j]
+}
+
+// psi returns the row of the suffix one position after the suffix in row
+// i, and the mapped symbol between them; the inverse of lf. The symbol is
+// the first column's, found in the C-table, and the next row is where its
+// occurrence is in the BWT, found by binary search in the O-table. It
+// returns symbol 0 for the sentinel row.
+func (idx *Index) psi(i int) (int, byte) {
+	cumsum := idx.ctab.cumsum
+	a := byte(sort.Search(len(cumsum), func(a int) bool { return cumsum[a] > i }) - 1)
+	if a == 0 {
+		return 0, 0
+	}
+	k := i - cumsum[a] // the occurrence of a to find
+	return sort.Search(len(idx.bwt), func(j int) bool { return idx.otab.Rank(a, j+1) > k }), a
+}
+
+// SuffixChars returns an iterator over the characters of the suffix in row
+// rank of the suffix array, excluding the sentinel. It steps forward
+// through the text one row at a time, from the tables alone, so it also
+// works on a compact index, takes O(log n) time per character, and stops
+// as soon as yield returns false, without building the suffix.
+func (idx *Index) SuffixChars(rank int) func(yield func(byte) bool) {
+	return func(yield func(byte) bool) {
+		for i := rank; ; {
+			next, a := idx.psi(i)
+			if a == 0 || !yield(idx.alpha.letters[a]) {
+				return
+			}
+			i = next
+		}
+	}
+}
+
+// ISA returns the inverse suffix array of x, isa[j] being the row of the
+// suffix that starts at j, for j from 0 to len(x). It uses only the BWT and
+// the tables, not the suffix array, so it also works on a compact index:
+// row 0 holds the suffix at len(x), the sentinel alone, and each LF step
+// from there moves to the row of the suffix one position earlier, so
+// len(x) steps fill in every position from the end of the text.
+func (idx *Index) ISA() []int32 {
+	isa := make([]int32, len(idx.bwt))
+	i := 0
+	for j := len(isa) - 1; j > 0; j-- {
+		isa[j] = int32(i)
+		i, _ = idx.lf(i)
+	}
+	isa[0] = int32(i) // the row with the sentinel in the BWT, where LF stops
+	return isa
+}
+
+// Locate returns the positions in x where p occurs. The positions are in
+// suffix-array order, so they are not sorted by position.
+func (idx *Index) Locate(p string) []int32 {
+	return idx.positions(search(idx, p))
+}
+
+// LocateBytes is Locate for a pattern given as a byte slice.
+func (idx *Index) LocateBytes(p []byte) []int32 {
+	return idx.positions(search(idx, p))
+}
+
+// LocateIntervals returns the positions where p occurs together with the
+// suffix-array interval [L, R) they come from, so positions[i] is the
+// suffix array at row L+i.
+func LocateIntervals(index *Index, p string) (L, R int, positions []int32) {
+	return locateIntervals(index, p)
+}
+
+// LocateIntervalsBytes is LocateIntervals for a pattern given as a byte
+// slice.
+func LocateIntervalsBytes(index *Index, p []byte) (L, R int, positions []int32) {
+	return locateIntervals(index, p)
+}
+
+func locateIntervals[P pattern](index *Index, p P) (L, R int, positions []int32) {
+	L, R = search(index, p)
+	return L, R, index.positions(L, R)
+}
+
+// locateCheckInterval is how many positions LocateContext resolves
+// between checks for cancellation.
+const locateCheckInterval = 1024
+
+// LocateContext returns the positions where p occurs, like Locate, but
+// checks ctx for cancellation while it resolves the positions, and returns
+// ctx.Err() and no positions if ctx is done before it finishes.
+func LocateContext(ctx context.Context, index *Index, p string) ([]int32, error) {
+	return locateContext(ctx, index, p)
+}
+
+// LocateContextBytes is LocateContext for a pattern given as a byte slice.
+func LocateContextBytes(ctx context.Context, index *Index, p []byte) ([]int32, error) {
+	return locateContext(ctx, index, p)
+}
+
+func locateContext[P pattern](ctx context.Context, index *Index, p P) ([]int32, error) {
+	L, R := search(index, p)
+	positions := make([]int32, 0, R-L)
+	for i := L; i < R; i++ {
+		if (i-L)%locateCheckInterval == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+		}
+		positions = append(positions, index.position(i))
+	}
+	return positions, nil
+}
+
+// extend maps the interval [L, R) of the suffixes that start with w to the
+// interval of the suffixes that start with aw. Characters outside the
+// alphabet give an empty interval.
+func (idx *Index) extend(a byte, L, R int) (int, int) {
+	s, ok := idx.alpha.Map(a)
+	if !ok {
+		return 0, 0
+	}
+	return extendInterval(s, L, R, idx.ctab, idx.otab)
+}
+
+// CountVerbose counts the occurrences of p like Count, but calls log after
+// each step of the backward search with the step number, the character
+// just processed and the resulting interval [L, R). The search stops after
+// the step where the interval becomes empty, so the last call shows where
+// a missing pattern fell out of the text. A pattern longer than the text
+// cannot occur, so it is rejected before any steps are taken and logged.
+func CountVerbose(index *Index, p string, log func(step int, a byte, L, R int)) int {
+	return countVerbose(index, p, log)
+}
+
+// CountVerboseBytes is CountVerbose for a pattern given as a byte slice.
+func CountVerboseBytes(index *Index, p []byte, log func(step int, a byte, L, R int)) int {
+	return countVerbose(index, p, log)
+}
+
+func countVerbose[P pattern](index *Index, p P, log func(step int, a byte, L, R int)) int {
+	if len(p) > index.Len() {
+		return 0
+	}
+	L, R := 0, len(index.bwt)
+	for i := len(p) - 1; i >= 0 && L < R; i-- {
+		L, R = index.extend(p[i], L, R)
+		log(len(p)-1-i, p[i], L, R)
+	}
+	return R - L
+}
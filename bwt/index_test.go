@@ -0,0 +1,457 @@
+package bwt
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// naiveOccurrences returns the positions where p occurs in x.
+func naiveOccurrences(x, p string) []int32 {
+	occ := []int32{}
+	for i := 0; i+len(p) <= len(x); i++ {
+		if strings.HasPrefix(x[i:], p) {
+			occ = append(occ, int32(i))
+		}
+	}
+	return occ
+}
+
+func TestCount(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(50, "acgt", rng)
+		idx := BuildIndex(x)
+		for j := 0; j < 10; j++ {
+			p := randomStringN(1+rng.Intn(4), "acgt", rng)
+			if count, expected := idx.Count(p), len(naiveOccurrences(x, p)); count != expected {
+				t.Errorf("Count(%q) in %q = %d, expected %d", p, x, count, expected)
+			}
+		}
+	}
+
+	idx := BuildIndex("mississippi")
+	if count := idx.Count(""); count != len("mississippi")+1 {
+		t.Errorf("Expected the empty pattern to match every suffix, got %d", count)
+	}
+	if count := idx.Count("x"); count != 0 {
+		t.Errorf("Expected no matches for a character not in the text, got %d", count)
+	}
+}
+
+func TestCountVerbose(t *testing.T) {
+	type entry struct {
+		step int
+		a    byte
+		L, R int
+	}
+
+	idx := BuildIndex("mississippi")
+	search := func(p string) (int, []entry) {
+		logged := []entry{}
+		count := CountVerbose(idx, p, func(step int, a byte, L, R int) {
+			logged = append(logged, entry{step, a, L, R})
+		})
+		return count, logged
+	}
+
+	count, logged := search("ssi")
+	if count != idx.Count("ssi") || len(logged) != 3 {
+		t.Fatalf("Expected three steps and count %d, got %d and %v",
+			idx.Count("ssi"), count, logged)
+	}
+	for i, e := range logged {
+		if e.step != i || e.a != "ssi"[2-i] {
+			t.Errorf("Unexpected log entry %v at step %d", e, i)
+		}
+		if L, R := idx.Search("ssi"[2-i:]); e.L != L || e.R != R {
+			t.Errorf("Step %d: expected interval [%d,%d), got [%d,%d)", i, L, R, e.L, e.R)
+		}
+	}
+
+	// "pss" collapses when we prepend p to "ss"
+	count, logged = search("pss")
+	if count != 0 || len(logged) != 3 {
+		t.Fatalf("Expected three steps and no matches, got %d and %v", count, logged)
+	}
+	if last := logged[len(logged)-1]; last.a != 'p' || last.L != last.R {
+		t.Errorf("Expected the interval to collapse at 'p', got %v", last)
+	}
+
+	count, logged = search("xss")
+	if count != 0 || len(logged) != 3 {
+		t.Errorf("Expected three steps and no matches, got %d and %v", count, logged)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		motifs := []string{"NNN", "ACGTA", "ACGTA", "TTT", "NNN", "NNN"}
+		x, planted := randomStringWithMotifs(100, "acgt", motifs, rng)
+		if len(x) != 100 {
+			t.Fatalf("Expected a string of length 100, got %d", len(x))
+		}
+
+		idx := BuildIndex(x)
+		for motif, positions := range planted {
+			located := idx.Locate(motif)
+			sort.Slice(located, func(i, j int) bool { return located[i] < located[j] })
+			if len(located) != len(positions) {
+				t.Fatalf("Expected %q at %v, located %v", motif, positions, located)
+			}
+			for j := range located {
+				if int(located[j]) != positions[j] {
+					t.Errorf("Expected %q at %v, located %v", motif, positions, located)
+				}
+			}
+		}
+	}
+
+	if located := BuildIndex("acgt").Locate("gg"); located == nil || len(located) != 0 {
+		t.Errorf("Expected an empty slice, got %v", located)
+	}
+}
+
+// cancelAfter is a context that is cancelled after Done has been called n
+// times, so tests can cancel at a deterministic point.
+type cancelAfter struct {
+	context.Context
+	n      int
+	closed chan struct{}
+}
+
+func newCancelAfter(n int) *cancelAfter {
+	closed := make(chan struct{})
+	close(closed)
+	return &cancelAfter{context.Background(), n, closed}
+}
+
+func (ctx *cancelAfter) Done() <-chan struct{} {
+	ctx.n--
+	if ctx.n < 0 {
+		return ctx.closed
+	}
+	return nil
+}
+
+func (ctx *cancelAfter) Err() error {
+	if ctx.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestLocateContext(t *testing.T) {
+	x := strings.Repeat("a", 5*locateCheckInterval)
+	idx := BuildIndex(x)
+
+	positions, err := LocateContext(context.Background(), idx, "a")
+	if err != nil || len(positions) != len(x) {
+		t.Fatalf("Expected %d positions, got %d (%v)", len(x), len(positions), err)
+	}
+
+	ctx := newCancelAfter(2)
+	positions, err = LocateContext(ctx, idx, "a")
+	if err != context.Canceled || positions != nil {
+		t.Errorf("Expected cancellation, got %d positions (%v)", len(positions), err)
+	}
+	if ctx.n != -1 {
+		t.Errorf("Expected locate to stop at the first check after cancellation")
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LocateContext(cancelled, idx, "a"); err != context.Canceled {
+		t.Errorf("Expected an already cancelled context to stop locate, got %v", err)
+	}
+}
+
+func TestPatternLongerThanText(t *testing.T) {
+	x := "acgtacgt"
+	p := x + "a"
+	idx := BuildIndex(x)
+
+	if L, R := idx.Search(p); L != R {
+		t.Errorf("Search: expected an empty interval, got [%d,%d)", L, R)
+	}
+	if count := idx.Count(p); count != 0 {
+		t.Errorf("Count: expected 0, got %d", count)
+	}
+	if located := idx.Locate(p); located == nil || len(located) != 0 {
+		t.Errorf("Locate: expected an empty slice, got %v", located)
+	}
+	if located, err := LocateContext(context.Background(), idx, p); err != nil || len(located) != 0 {
+		t.Errorf("LocateContext: expected no positions, got %v (%v)", located, err)
+	}
+	steps := 0
+	if count := CountVerbose(idx, p, func(int, byte, int, int) { steps++ }); count != 0 || steps != 0 {
+		t.Errorf("CountVerbose: expected 0 without steps, got %d after %d steps", count, steps)
+	}
+	if count := BuildLazyIndex(x).Count(p); count != 0 {
+		t.Errorf("LazyIndex.Count: expected 0, got %d", count)
+	}
+	csa := NewCSA(x, 2)
+	if count, located := csa.Count(p), csa.Locate(p); count != 0 || len(located) != 0 {
+		t.Errorf("CSA: expected no matches, got %d and %v", count, located)
+	}
+
+	if count := CountWithTranspositions(idx, p, 2); count != 0 {
+		t.Errorf("CountWithTranspositions: expected 0, got %d", count)
+	}
+	if hist := ApproxHistogram(idx, p, 2); hist[0]+hist[1]+hist[2] != 0 {
+		t.Errorf("ApproxHistogram: expected no matches, got %v", hist)
+	}
+	if matches := SeedAndExtend(idx, p+"cc", 4, 2); len(matches) != 0 {
+		t.Errorf("SeedAndExtend: expected no matches, got %v", matches)
+	}
+	// With deletions, a longer pattern can still match.
+	if matches := SeedAndExtend(idx, p, 4, 1); len(matches) != 1 {
+		t.Errorf("SeedAndExtend: expected one match with a deletion, got %v", matches)
+	}
+	if match, edits := ClosestMatch(idx, p); match != x || edits != 1 {
+		t.Errorf("ClosestMatch: expected %q with one edit, got %q with %d", x, match, edits)
+	}
+	if pairs := CoOccur(idx, p, "a", 10); len(pairs) != 0 {
+		t.Errorf("CoOccur: expected no pairs, got %v", pairs)
+	}
+}
+
+func TestSuffixChars(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "mississippi", randomStringN(100, "acgt", rng)} {
+		sa := PrefixDoubling(x)
+		for _, idx := range []*Index{BuildIndex(x), BuildIndexCompact(x)} {
+			for rank, pos := range sa {
+				suffix := []byte{}
+				idx.SuffixChars(rank)(func(a byte) bool {
+					suffix = append(suffix, a)
+					return true
+				})
+				if string(suffix) != x[pos:] {
+					t.Errorf("Expected suffix %q in row %d, got %q", x[pos:], rank, suffix)
+				}
+			}
+		}
+	}
+
+	idx := BuildIndex("mississippi")
+	prefix := []byte{}
+	idx.SuffixChars(11)(func(a byte) bool {
+		prefix = append(prefix, a)
+		return len(prefix) < 3
+	})
+	if string(prefix) != "ssi" {
+		t.Errorf("Expected the iterator to stop after \"ssi\", got %q", prefix)
+	}
+}
+
+func TestLocateIntervals(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(100, "acgt", rng)
+	idx := BuildIndex(x)
+	sa := PrefixDoubling(x)
+	for _, p := range []string{"", "a", "ac", randomStringN(3, "acgt", rng), "acgtn"} {
+		L, R, positions := LocateIntervals(idx, p)
+		if R-L != len(positions) || len(positions) != idx.Count(p) {
+			t.Errorf("%q: interval [%d,%d), %d positions, count %d", p, L, R, len(positions), idx.Count(p))
+		}
+		for i, pos := range positions {
+			if pos != sa[L+i] {
+				t.Errorf("%q: position %d is %d, expected sa[%d] = %d", p, i, pos, L+i, sa[L+i])
+			}
+		}
+	}
+}
+
+func TestLargeAlphabet(t *testing.T) {
+	rng := newRandomSeed(t)
+	letters := make([]byte, 0, 200)
+	for a := 32; a < 232; a++ {
+		letters = append(letters, byte(a))
+	}
+	x := randomStringN(2000, string(letters), rng)
+	for _, idx := range []*Index{BuildIndex(x), BuildIndexCompact(x)} {
+		if _, ok := idx.otab.(*waveletOTab); !ok {
+			t.Fatalf("Expected a wavelet O-table for %d characters, got %T", idx.alpha.Size()-1, idx.otab)
+		}
+		for i := 0; i < 50; i++ {
+			start := rng.Intn(len(x) - 3)
+			p := x[start : start+1+rng.Intn(3)]
+			if locate, expected := sortedLocate(idx, p), naiveOccurrences(x, p); !reflect.DeepEqual(locate, expected) {
+				t.Errorf("Expected %q at %v, got %v", p, expected, locate)
+			}
+		}
+		if idx.otab.SizeBytes() >= NewOTab(idx.bwt, idx.alpha.Size()).SizeBytes()/10 {
+			t.Errorf("Expected the wavelet O-table to be much smaller than an OTab, got %d bytes", idx.otab.SizeBytes())
+		}
+	}
+
+	fm := NewFMIndex(x)
+	if _, ok := fm.otab.(*waveletOTab); !ok {
+		t.Fatalf("Expected a wavelet O-table in the FMIndex, got %T", fm.otab)
+	}
+	for i := 0; i < 50; i++ {
+		start := rng.Intn(len(x) - 3)
+		p := x[start : start+1+rng.Intn(3)]
+		locate := fm.Locate(p)
+		sort.Ints(locate)
+		expected := []int{}
+		for _, pos := range naiveOccurrences(x, p) {
+			expected = append(expected, int(pos))
+		}
+		if !reflect.DeepEqual(locate, expected) {
+			t.Errorf("Expected %q at %v in the FMIndex, got %v", p, expected, locate)
+		}
+	}
+	if y := fm.Reverse(); y != x {
+		t.Errorf("Expected Reverse to give back the text of the FMIndex")
+	}
+
+	if _, ok := BuildIndex(randomStringN(100, "acgt", rng)).otab.(*OTab); !ok {
+		t.Errorf("Expected an OTab for a small alphabet")
+	}
+}
+
+func TestBuildIndexes(t *testing.T) {
+	rng := newRandomSeed(t)
+	seqs := []string{"", "a", "gattaca", randomStringN(100, "acgt", rng), randomStringN(50, "ag", rng)}
+	shared := NewAlphabet("acgt")
+	for _, alpha := range []*Alphabet{shared, nil} {
+		indexes, err := BuildIndexes(seqs, alpha)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, idx := range indexes {
+			if idx.Alphabet() != indexes[0].Alphabet() || !reflect.DeepEqual(idx.Alphabet().letters, shared.letters) {
+				t.Errorf("Expected sequence %d to use the shared alphabet %q, got %q", k, shared.letters, idx.Alphabet().letters)
+			}
+			if y, _ := idx.Alphabet().Unmap(reverseBwt(idx.BWT(), idx.Alphabet().Size())); y != seqs[k] {
+				t.Errorf("Expected the BWT of sequence %d to invert to %q, got %q", k, seqs[k], y)
+			}
+			for _, p := range []string{"a", "ga", "t"} {
+				if count, expected := idx.Count(p), len(naiveOccurrences(seqs[k], p)); count != expected {
+					t.Errorf("Expected %d occurrences of %q in sequence %d, got %d", expected, p, k, count)
+				}
+			}
+		}
+	}
+
+	if _, err := BuildIndexes([]string{"acgt", "acgn"}, shared); err == nil {
+		t.Errorf("Expected an error for a character outside the alphabet")
+	}
+}
+
+func TestBuildIndexCompact(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, n := range []int{0, 1, 31, 32, 33, 200, 1000} {
+		x := randomStringN(n, "acgt", rng)
+		full, compact := BuildIndex(x), BuildIndexCompact(x)
+		if compact.Len() != n {
+			t.Errorf("Expected length %d, got %d", n, compact.Len())
+		}
+		for j := 0; j < 20; j++ {
+			p := randomStringN(1+rng.Intn(4), "acgt", rng)
+			if count, expected := compact.Count(p), full.Count(p); count != expected {
+				t.Errorf("Count(%q) in %q = %d, expected %d", p, x, count, expected)
+			}
+			positions, expected := compact.Locate(p), full.Locate(p)
+			if !reflect.DeepEqual(positions, expected) {
+				t.Errorf("Locate(%q) in %q = %v, expected %v", p, x, positions, expected)
+			}
+		}
+		for j := 0; j < 20; j++ {
+			i := rng.Intn(n + 1)
+			k := i + rng.Intn(n-i+1)
+			if y := compact.Extract(i, k); y != x[i:k] {
+				t.Errorf("Extract(%d, %d) of %q = %q, expected %q", i, k, x, y, x[i:k])
+			}
+		}
+
+		// The resident size must match the footprint documented on
+		// BuildIndexCompact.
+		sigma := compact.Alphabet().Size() - 1
+		words := (n + 64) / 64
+		expected := (n + 1) + (sigma+1)*intSize + sigma*(n+1)*intSize +
+			words*8 + (words+1)*intSize + compact.samples.marked.selectSizeBytes() +
+			2*(n/compactSampleRate+1)*int32Size
+		if size := compact.SizeBytes(); size != expected {
+			t.Errorf("Expected a compact index of %d bytes for n = %d, got %d", expected, n, size)
+		}
+		if n >= 200 && compact.SizeBytes() >= full.SizeBytes() {
+			t.Errorf("Expected the compact index (%d bytes) to be smaller than the full (%d bytes)",
+				compact.SizeBytes(), full.SizeBytes())
+		}
+	}
+}
+
+func TestBytesVariants(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(300, "acgt", rng)
+	idx, compact := BuildIndex(x), BuildIndexCompact(x)
+	csa, lazy, g := NewCSA(x, 8), BuildLazyIndex(x), CDAWG(x)
+	gen := BuildGeneralizedIndex([]string{x[:100], x[100:]})
+	strands := BuildDoubleStrandIndex(x)
+	ctx := context.Background()
+	for j := 0; j < 20; j++ {
+		s := randomStringN(rng.Intn(8), "acgt", rng)
+		b := []byte(s)
+		check := func(name string, a, b interface{}) {
+			t.Helper()
+			if !reflect.DeepEqual(a, b) {
+				t.Errorf("%s(%q): string gives %v, bytes give %v", name, s, a, b)
+			}
+		}
+		pair := func(L, R int) [2]int { return [2]int{L, R} }
+
+		check("Search", pair(idx.Search(s)), pair(idx.SearchBytes(b)))
+		check("Count", idx.Count(s), idx.CountBytes(b))
+		check("Locate", idx.Locate(s), idx.LocateBytes(b))
+		check("Locate (compact)", compact.Locate(s), compact.LocateBytes(b))
+		L1, R1, pos1 := LocateIntervals(idx, s)
+		L2, R2, pos2 := LocateIntervalsBytes(idx, b)
+		check("LocateIntervals", []interface{}{L1, R1, pos1}, []interface{}{L2, R2, pos2})
+		c1, _ := LocateContext(ctx, idx, s)
+		c2, _ := LocateContextBytes(ctx, idx, b)
+		check("LocateContext", c1, c2)
+		noLog := func(step int, a byte, L, R int) {}
+		check("CountVerbose", CountVerbose(idx, s, noLog), CountVerboseBytes(idx, b, noLog))
+		check("CountWithTranspositions", CountWithTranspositions(idx, s, 1), CountWithTranspositionsBytes(idx, b, 1))
+		check("ApproxHistogram", ApproxHistogram(idx, s, 2), ApproxHistogramBytes(idx, b, 2))
+		m1, e1 := ClosestMatch(idx, s+"x")
+		m2, e2 := ClosestMatchBytes(idx, append(b, 'x'))
+		check("ClosestMatch", []interface{}{m1, e1}, []interface{}{string(m2), e2})
+		check("SeedAndExtend", SeedAndExtend(idx, s, 2, 1), SeedAndExtendBytes(idx, b, 2, 1))
+		check("MatchingStatistics", MatchingStatistics(idx, s), MatchingStatisticsBytes(idx, b))
+		check("CoOccur", CoOccur(idx, s, "ac", 5), CoOccurBytes(idx, b, []byte("ac"), 5))
+		check("Coverage", Coverage(idx, []string{s, "ac"}), CoverageBytes(idx, [][]byte{b, []byte("ac")}))
+		check("RangeCount", RangeCount(idx, s, 50, 200), RangeCountBytes(idx, b, 50, 200))
+		check("AllSuffixCounts", AllSuffixCounts(idx, s), AllSuffixCountsBytes(idx, b))
+		check("CSA.Count", csa.Count(s), csa.CountBytes(b))
+		check("CSA.Locate", csa.Locate(s), csa.LocateBytes(b))
+		check("LazyIndex.Search", pair(lazy.Search(s)), pair(lazy.SearchBytes(b)))
+		check("LazyIndex.Count", lazy.Count(s), lazy.CountBytes(b))
+		check("CDAWGraph.Count", g.Count(s), g.CountBytes(b))
+		all := map[int]bool{0: true, 1: true}
+		check("LocateInDocs", LocateInDocs(gen, s, all), LocateInDocsBytes(gen, b, all))
+		check("LocateDoubleStrand", LocateDoubleStrand(strands, s), LocateDoubleStrandBytes(strands, b))
+	}
+}
+
+func TestISA(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "a", "mississippi", randomStringN(300, "acgt", rng)} {
+		sa := PrefixDoubling(x)
+		expected := make([]int32, len(sa))
+		for i, j := range sa {
+			expected[j] = int32(i)
+		}
+		for _, idx := range []*Index{BuildIndex(x), BuildIndexCompact(x)} {
+			if isa := idx.ISA(); !reflect.DeepEqual(isa, expected) {
+				t.Errorf("Expected the inverse suffix array %v of %q, got %v", expected, x, isa)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package bwt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// PrefixDoublingInts computes the suffix array of a string over an integer
+// alphabet, such as a tokenized text of word IDs, with the same sentinel
+// convention as PrefixDoubling: the result has length len(x)+1, and the
+// sentinel, smaller than every value, puts len(x) first. The values are
+// ranked by their order, so they can be any int32s, negative or sparse.
+func PrefixDoublingInts(x []int32) []int32 {
+	if len(x) >= math.MaxInt32 {
+		panic("bwt: string too long for 32-bit positions")
+	}
+	values := append([]int32{}, x...)
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	distinct := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			distinct = append(distinct, v)
+		}
+	}
+
+	rank := make([]int32, len(x)+1) // the sentinel's rank is 0
+	for i, v := range x {
+		rank[i] = int32(sort.Search(len(distinct), func(j int) bool { return distinct[j] >= v })) + 1
+	}
+	sa := make([]int32, len(rank))
+	for i := range sa {
+		sa[i] = int32(i)
+	}
+	sa, _ = checkpointRounds(sa, rank, 1, nil)
+	return sa
+}
+
+// ReadInts reads whitespace-separated decimal int32s from r, such as a
+// file with one token ID per line.
+func ReadInts(r io.Reader) ([]int32, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	ints := []int32{}
+	for scanner.Scan() {
+		v, err := strconv.ParseInt(scanner.Text(), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("integer %d: %w", len(ints), err)
+		}
+		ints = append(ints, int32(v))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ints, nil
+}
+
+// PrefixDoublingIntsFromReader reads a string of integers from r with
+// ReadInts and returns its suffix array from PrefixDoublingInts.
+func PrefixDoublingIntsFromReader(r io.Reader) ([]int32, error) {
+	x, err := ReadInts(r)
+	if err != nil {
+		return nil, err
+	}
+	return PrefixDoublingInts(x), nil
+}
@@ -0,0 +1,81 @@
+package bwt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPrefixDoublingInts(t *testing.T) {
+	// The suffix array of ints with values 1, 2, ... is that of the byte
+	// string with the same order.
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(rng.Intn(100), "acgt", rng)
+		ints := make([]int32, len(x))
+		for j := range x {
+			ints[j] = int32(x[j]) * 1000
+		}
+		if sa, expected := PrefixDoublingInts(ints), PrefixDoubling(x); !reflect.DeepEqual(sa, expected) {
+			t.Errorf("Expected the suffix array %v of %v, got %v", expected, ints, sa)
+		}
+	}
+
+	// Compare suffixes directly for values outside the byte range.
+	less := func(a, b []int32) bool {
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	}
+	for i := 0; i < 10; i++ {
+		x := make([]int32, rng.Intn(100))
+		for j := range x {
+			x[j] = int32(rng.Intn(5)-2) * 100000
+		}
+		expected := make([]int32, len(x)+1)
+		for j := range expected {
+			expected[j] = int32(j)
+		}
+		sort.Slice(expected, func(a, b int) bool { return less(x[expected[a]:], x[expected[b]:]) })
+		if sa := PrefixDoublingInts(x); !reflect.DeepEqual(sa, expected) {
+			t.Errorf("Expected the suffix array %v of %v, got %v", expected, x, sa)
+		}
+	}
+}
+
+func TestPrefixDoublingIntsFromReader(t *testing.T) {
+	x := []int32{3, 1, 4, 1, 5, 9, 2, 6, 5, 3, 5, -7, 1 << 30}
+	var text strings.Builder
+	for i, v := range x {
+		if i%3 == 0 {
+			text.WriteString("\n")
+		}
+		fmt.Fprintf(&text, "%d ", v)
+	}
+
+	ints, err := ReadInts(strings.NewReader(text.String()))
+	if err != nil || !reflect.DeepEqual(ints, x) {
+		t.Fatalf("Expected to read %v, got %v (%v)", x, ints, err)
+	}
+	sa, err := PrefixDoublingIntsFromReader(strings.NewReader(text.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := PrefixDoublingInts(x); !reflect.DeepEqual(sa, expected) {
+		t.Errorf("Expected the suffix array %v, got %v", expected, sa)
+	}
+
+	if sa, err := PrefixDoublingIntsFromReader(strings.NewReader("")); err != nil || !reflect.DeepEqual(sa, []int32{0}) {
+		t.Errorf("Expected the sentinel alone for no integers, got %v (%v)", sa, err)
+	}
+	for _, bad := range []string{"1 2 x", "1 99999999999"} {
+		if _, err := ReadInts(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error reading %q", bad)
+		}
+	}
+}
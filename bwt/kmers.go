@@ -0,0 +1,149 @@
+package bwt
+
+import "sort"
+
+// forEachKmer calls visit with each distinct k-mer in the indexed text and
+// its suffix-array interval [L, R). The k-mers are enumerated by extending
+// backward search with every symbol, so only k-mers that occur are
+// visited, and k-mers that would contain the sentinel are skipped. A
+// negative k has no k-mers.
+func forEachKmer(index *Index, k int, visit func(kmer string, L, R int)) {
+	if k < 0 {
+		return
+	}
+	kmer := make([]byte, k)
+	var extend func(i, L, R int)
+	extend = func(i, L, R int) {
+		if i < 0 {
+			visit(string(kmer), L, R)
+			return
+		}
+		for a := byte(1); int(a) < index.alpha.Size(); a++ {
+			if l, r := extendInterval(a, L, R, index.ctab, index.otab); l < r {
+				kmer[i] = index.alpha.letters[a]
+				extend(i-1, l, r)
+			}
+		}
+	}
+	extend(k-1, 0, len(index.bwt))
+}
+
+// IsDeBruijn reports whether x is a de Bruijn sequence of order k over an
+// alphabet of sigma characters: whether every string of length k over x's
+// alphabet, which must have exactly sigma characters, occurs exactly once
+// in x read cyclically. The k-mers are counted by enumerating them in the
+// index of x extended with its first k-1 characters, so the k-mers that
+// wrap around are included.
+func IsDeBruijn(x string, k, sigma int) bool {
+	if k < 1 || sigma < 1 || len(x) == 0 {
+		return false
+	}
+	// A de Bruijn sequence has length sigma^k.
+	expected := 1
+	for i := 0; i < k; i++ {
+		expected *= sigma
+		if expected > len(x) {
+			return false
+		}
+	}
+	if expected != len(x) {
+		return false
+	}
+
+	y := []byte(x)
+	for i := 0; i < k-1; i++ {
+		y = append(y, x[i%len(x)])
+	}
+	index := BuildIndex(string(y))
+	if index.alpha.Size()-1 != sigma {
+		return false
+	}
+
+	distinct, once := 0, true
+	forEachKmer(index, k, func(kmer string, L, R int) {
+		distinct++
+		once = once && R-L == 1
+	})
+	return once && distinct == expected
+}
+
+// KmerJaccard returns the Jaccard similarity of the sets of distinct
+// k-mers of the texts indexed by a and b: the size of their intersection
+// divided by the size of their union. The k-mers of each text are
+// enumerated from its index, and those of a are looked up in b by
+// backward search, so no k-mer set is stored. It returns 0 if k < 1 or
+// neither text has a k-mer.
+func KmerJaccard(a, b *Index, k int) float64 {
+	if k < 1 {
+		return 0
+	}
+	inA, common := 0, 0
+	forEachKmer(a, k, func(kmer string, L, R int) {
+		inA++
+		if b.Count(kmer) > 0 {
+			common++
+		}
+	})
+	inB := 0
+	forEachKmer(b, k, func(string, int, int) { inB++ })
+	if union := inA + inB - common; union > 0 {
+		return float64(common) / float64(union)
+	}
+	return 0
+}
+
+// DiscriminativeKmers returns the distinct k-mers of the text indexed by
+// target that occur in none of the texts indexed by others, sorted. The
+// k-mers of target are enumerated from its index and each is looked up in
+// the others by backward search, stopping at the first index that has
+// it. It returns no k-mers if k < 1.
+func DiscriminativeKmers(target *Index, others []*Index, k int) []string {
+	kmers := []string{}
+	if k < 1 {
+		return kmers
+	}
+	forEachKmer(target, k, func(kmer string, L, R int) {
+		for _, other := range others {
+			if other.Count(kmer) > 0 {
+				return
+			}
+		}
+		kmers = append(kmers, kmer)
+	})
+	sort.Strings(kmers)
+	return kmers
+}
+
+// KmerDensity returns, for each of kmers, the number of its occurrences
+// that start in each bin of binSize text positions: density[i][b] counts
+// the occurrences of kmers[i] in [b*binSize, (b+1)*binSize). There are
+// ceil(n/binSize) bins for a text of length n, the last possibly shorter.
+// It returns nil if binSize < 1.
+func KmerDensity(index *Index, kmers []string, binSize int) [][]int {
+	return kmerDensity(index, kmers, binSize)
+}
+
+// KmerDensityBytes is KmerDensity for k-mers given as byte slices.
+func KmerDensityBytes(index *Index, kmers [][]byte, binSize int) [][]int {
+	return kmerDensity(index, kmers, binSize)
+}
+
+func kmerDensity[P pattern](index *Index, kmers []P, binSize int) [][]int {
+	if binSize < 1 {
+		return nil
+	}
+	bins := (index.Len() + binSize - 1) / binSize
+	density := make([][]int, len(kmers))
+	for i, kmer := range kmers {
+		density[i] = make([]int, bins)
+		if len(kmer) > index.Len() {
+			continue
+		}
+		for _, pos := range index.positions(search(index, kmer)) {
+			if int(pos) < index.Len() { // not the sentinel, for an empty k-mer
+				density[i][int(pos)/binSize]++
+			}
+		}
+	}
+	return density
+}
@@ -0,0 +1,135 @@
+package bwt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsDeBruijn(t *testing.T) {
+	tests := []struct {
+		x        string
+		k, sigma int
+		expected bool
+	}{
+		{"0011", 2, 2, true},
+		{"00010111", 3, 2, true},
+		{"aabacbbcc", 2, 3, true},
+		{"0000111101100101", 4, 2, true},
+		{"a", 3, 1, true},
+		{"0101", 2, 2, false},     // 00 and 11 are missing
+		{"00010111", 3, 3, false}, // too short for three characters
+		{"00110101", 3, 2, false}, // 101 and 010 occur twice
+		{"0011", 2, 3, false},     // the alphabet has two characters
+		{"abcd", 1, 4, true},
+		{"abca", 1, 3, false},
+		{"", 1, 1, false},
+	}
+	for _, test := range tests {
+		if result := IsDeBruijn(test.x, test.k, test.sigma); result != test.expected {
+			t.Errorf("IsDeBruijn(%q, %d, %d) = %v, expected %v", test.x, test.k, test.sigma, result, test.expected)
+		}
+	}
+}
+
+func TestKmerJaccard(t *testing.T) {
+	naive := func(x, y string, k int) float64 {
+		kmers := func(x string) map[string]bool {
+			set := map[string]bool{}
+			for i := 0; i+k <= len(x); i++ {
+				set[x[i:i+k]] = true
+			}
+			return set
+		}
+		a, b := kmers(x), kmers(y)
+		common := 0
+		for kmer := range a {
+			if b[kmer] {
+				common++
+			}
+		}
+		if union := len(a) + len(b) - common; union > 0 {
+			return float64(common) / float64(union)
+		}
+		return 0
+	}
+
+	tests := []struct {
+		x, y     string
+		k        int
+		expected float64
+	}{
+		{"acgtacgt", "acgtacgt", 3, 1}, // identical
+		{"aaaa", "cccc", 2, 0},         // disjoint
+		{"abcd", "bcde", 2, 2.0 / 4},   // ab bc cd vs bc cd de
+		{"abab", "ab", 2, 1.0 / 2},     // ab ba vs ab
+		{"ab", "abc", 3, 0},            // only abc
+		{"ab", "ab", 3, 0},             // no k-mers at all
+		{"acgt", "acgt", 0, 0},         // invalid k
+	}
+	for _, test := range tests {
+		got := KmerJaccard(BuildIndex(test.x), BuildIndex(test.y), test.k)
+		if got != test.expected {
+			t.Errorf("KmerJaccard(%q, %q, %d) = %v, expected %v", test.x, test.y, test.k, got, test.expected)
+		}
+	}
+
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x, y := randomStringN(50, "acgt", rng), randomStringN(50, "acgt", rng)
+		k := 1 + rng.Intn(4)
+		if got, expected := KmerJaccard(BuildIndex(x), BuildIndex(y), k), naive(x, y, k); got != expected {
+			t.Errorf("KmerJaccard(%q, %q, %d) = %v, expected %v", x, y, k, got, expected)
+		}
+	}
+}
+
+func TestKmerDensity(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(1000, "acgt", rng)
+	idx := BuildIndex(x)
+	kmers := []string{"a", "ac", "gtt", "acgta", "nnn"}
+	for _, binSize := range []int{1, 7, 100, 1000, 5000} {
+		density := KmerDensity(idx, kmers, binSize)
+		bins := (len(x) + binSize - 1) / binSize
+		for i, kmer := range kmers {
+			expected := make([]int, bins)
+			for _, pos := range naiveOccurrences(x, kmer) {
+				expected[int(pos)/binSize]++
+			}
+			if !reflect.DeepEqual(density[i], expected) {
+				t.Errorf("Expected density %v of %q in bins of %d, got %v", expected, kmer, binSize, density[i])
+			}
+		}
+		byteKmers := make([][]byte, len(kmers))
+		for i, kmer := range kmers {
+			byteKmers[i] = []byte(kmer)
+		}
+		if got := KmerDensityBytes(idx, byteKmers, binSize); !reflect.DeepEqual(got, density) {
+			t.Errorf("Expected KmerDensityBytes to agree in bins of %d", binSize)
+		}
+	}
+	if density := KmerDensity(idx, kmers, 0); density != nil {
+		t.Errorf("Expected no density for bins of size 0, got %v", density)
+	}
+}
+
+func TestDiscriminativeKmers(t *testing.T) {
+	target := BuildIndex("acgtacgg")
+	others := []*Index{BuildIndex("acgtt"), BuildIndex("tacg")}
+	// The 3-mers of the target are acg, cgt, gta, tac and cgg; acg, cgt
+	// and tac occur in the others.
+	if kmers, expected := DiscriminativeKmers(target, others, 3), []string{"cgg", "gta"}; !reflect.DeepEqual(kmers, expected) {
+		t.Errorf("Expected %v, got %v", expected, kmers)
+	}
+	if kmers, expected := DiscriminativeKmers(target, nil, 2), []string{"ac", "cg", "gg", "gt", "ta"}; !reflect.DeepEqual(kmers, expected) {
+		t.Errorf("Expected all 2-mers without other texts, got %v", kmers)
+	}
+	if kmers := DiscriminativeKmers(target, []*Index{target}, 3); len(kmers) != 0 {
+		t.Errorf("Expected no k-mers unique to a text among itself, got %v", kmers)
+	}
+	for _, k := range []int{0, -1, 9} {
+		if kmers := DiscriminativeKmers(target, others, k); kmers == nil || len(kmers) != 0 {
+			t.Errorf("Expected an empty result for k = %d, got %#v", k, kmers)
+		}
+	}
+}
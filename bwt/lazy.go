@@ -0,0 +1,75 @@
+package bwt
+
+// LazyIndex is an FM-index that builds its O-table on demand. The BWT and
+// C-table are computed up front, but the O-table row for a character is
+// only computed, and then cached, the first time a query needs it. This
+// makes construction cheaper when many texts are indexed but few are
+// queried, at the cost of a slower first query.
+//
+// A LazyIndex is not safe for concurrent use.
+type LazyIndex struct {
+	bwt   []byte
+	alpha *Alphabet
+	ctab  *CTab
+	rows  [][]int // rows[a][i] is the number of a's in bwt[:i+1]
+}
+
+// BuildLazyIndex builds a lazy FM-index for x. The suffix array is only
+// used to compute the BWT and is not kept.
+func BuildLazyIndex(x string) *LazyIndex {
+	_, bwt, alpha := mappedBwt(x)
+	return &LazyIndex{
+		bwt:   bwt,
+		alpha: alpha,
+		ctab:  NewCTab(bwt, alpha.Size()),
+		rows:  make([][]int, alpha.Size()),
+	}
+}
+
+// row returns the O-table row for a, computing it if necessary.
+func (idx *LazyIndex) row(a byte) []int {
+	if idx.rows[a] == nil {
+		row := make([]int, len(idx.bwt))
+		count := 0
+		for i, b := range idx.bwt {
+			if b == a {
+				count++
+			}
+			row[i] = count
+		}
+		idx.rows[a] = row
+	}
+	return idx.rows[a]
+}
+
+// Rank returns the number of occurrences of the symbol a in the BWT's
+// prefix [:i].
+func (idx *LazyIndex) Rank(a byte, i int) int {
+	if i == 0 {
+		return 0
+	}
+	return idx.row(a)[i-1]
+}
+
+// Search returns the suffix-array interval [L, R) of the suffixes that
+// have p as a prefix.
+func (idx *LazyIndex) Search(p string) (L, R int) {
+	return backwardSearch(p, len(idx.bwt), idx.alpha, idx.ctab, idx)
+}
+
+// SearchBytes is Search for a pattern given as a byte slice.
+func (idx *LazyIndex) SearchBytes(p []byte) (L, R int) {
+	return backwardSearch(p, len(idx.bwt), idx.alpha, idx.ctab, idx)
+}
+
+// Count returns the number of occurrences of p in the indexed string.
+func (idx *LazyIndex) Count(p string) int {
+	L, R := idx.Search(p)
+	return R - L
+}
+
+// CountBytes is Count for a pattern given as a byte slice.
+func (idx *LazyIndex) CountBytes(p []byte) int {
+	L, R := idx.SearchBytes(p)
+	return R - L
+}
@@ -0,0 +1,28 @@
+package bwt
+
+import (
+	"testing"
+)
+
+func TestLazyIndex(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(50, "acgt", rng)
+		eager, lazy := BuildIndex(x), BuildLazyIndex(x)
+		for j := 0; j < 10; j++ {
+			p := randomStringN(1+rng.Intn(4), "acgt", rng)
+			if eager.Count(p) != lazy.Count(p) {
+				t.Errorf("Count(%q) in %q: eager %d, lazy %d",
+					p, x, eager.Count(p), lazy.Count(p))
+			}
+		}
+	}
+
+	lazy := BuildLazyIndex("aaab")
+	lazy.Count("b")
+	a, _ := lazy.alpha.Map('a')
+	b, _ := lazy.alpha.Map('b')
+	if lazy.rows[a] != nil || lazy.rows[b] == nil {
+		t.Errorf("Expected only the queried characters' rows to be built")
+	}
+}
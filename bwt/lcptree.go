@@ -0,0 +1,139 @@
+package bwt
+
+// Interval is a node of the implicit suffix tree of a string: the rows
+// [L, R) of its suffix array, whose suffixes share a prefix of Depth
+// characters. Internal nodes are lcp intervals, with at least two rows,
+// and leaves are single rows whose depth is the length of their suffix.
+type Interval struct {
+	L, R, Depth int
+}
+
+// Count returns the number of suffixes in the interval, i.e., the number
+// of occurrences of the interval's prefix.
+func (iv Interval) Count() int {
+	return iv.R - iv.L
+}
+
+// LCPIntervalTree is the implicit suffix tree of a string, given by its
+// suffix array, its lcp array and the child table of Abouelhoda, Kurtz and
+// Ohlebusch, which finds each child of a node in constant time. The
+// sentinel is the last character of every suffix, so the first child of
+// the root is the leaf for the empty suffix.
+type LCPIntervalTree struct {
+	x   string
+	sa  []int32
+	lcp []int32 // the lcp array, with -1 at both ends
+	// up[i] and down[i] are the leftmost minimum of the lcp values larger
+	// than lcp[i] that are next to i, to the left and right respectively,
+	// and nextl[i] is the next index with the same lcp value as i and only
+	// larger ones between them. All are -1 if there is no such index.
+	up, down, nextl []int32
+}
+
+// NewLCPIntervalTree builds the LCP interval tree of x. It takes
+// O(n log n) time, for building the suffix array, and stores five arrays
+// of n+1 integers.
+func NewLCPIntervalTree(x string) *LCPIntervalTree {
+	sa := PrefixDoubling(x)
+	n := len(sa)
+	lcp := append(Lcp(x, sa), -1)
+	lcp[0] = -1
+
+	t := &LCPIntervalTree{
+		x: x, sa: sa, lcp: lcp,
+		up:    make([]int32, n+1),
+		down:  make([]int32, n+1),
+		nextl: make([]int32, n+1),
+	}
+	for i := range t.up {
+		t.up[i], t.down[i], t.nextl[i] = -1, -1, -1
+	}
+
+	stack := []int32{0}
+	for i := int32(1); i <= int32(n); i++ {
+		last := int32(-1)
+		for len(stack) > 0 && lcp[stack[len(stack)-1]] > lcp[i] {
+			last, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		}
+		t.up[i] = last
+		stack = append(stack, i)
+	}
+
+	stack = []int32{0}
+	for i := int32(1); i <= int32(n); i++ {
+		for len(stack) > 0 && lcp[stack[len(stack)-1]] > lcp[i] {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 && lcp[stack[len(stack)-1]] == lcp[i] {
+			t.nextl[stack[len(stack)-1]] = i
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, i)
+	}
+
+	// From the right, equal values replace each other on the stack, so
+	// the one left is the leftmost.
+	stack = []int32{int32(n)}
+	for i := int32(n) - 1; i >= 0; i-- {
+		last := int32(-1)
+		for len(stack) > 0 && lcp[stack[len(stack)-1]] > lcp[i] {
+			last, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		}
+		t.down[i] = last
+		for len(stack) > 0 && lcp[stack[len(stack)-1]] == lcp[i] {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, i)
+	}
+	return t
+}
+
+// Root returns the root of the tree, the interval of all suffixes.
+func (t *LCPIntervalTree) Root() Interval {
+	return t.node(0, len(t.sa))
+}
+
+// firstLIndex returns the first index in (L, R) where the lcp array has
+// the minimum of lcp[L+1:R], the boundary between the first two children
+// of the lcp interval [L, R).
+func (t *LCPIntervalTree) firstLIndex(L, R int) int {
+	if t.lcp[L] <= t.lcp[R] {
+		return int(t.up[R])
+	}
+	return int(t.down[L])
+}
+
+// node returns the interval of the rows [L, R) with its depth.
+func (t *LCPIntervalTree) node(L, R int) Interval {
+	if R-L == 1 {
+		return Interval{L, R, len(t.x) - int(t.sa[L])}
+	}
+	return Interval{L, R, int(t.lcp[t.firstLIndex(L, R)])}
+}
+
+// Children returns the children of the node iv in lexicographic order, or
+// nil if iv is a leaf. The interval must be a node of the tree.
+func (t *LCPIntervalTree) Children(iv Interval) []Interval {
+	if iv.R-iv.L < 2 {
+		return nil
+	}
+	children := []Interval{}
+	start := iv.L
+	for k := t.firstLIndex(iv.L, iv.R); k >= 0 && k < iv.R; k = int(t.nextl[k]) {
+		children = append(children, t.node(start, k))
+		start = k
+	}
+	return append(children, t.node(start, iv.R))
+}
+
+// Label returns the common prefix of the suffixes in iv.
+func (t *LCPIntervalTree) Label(iv Interval) string {
+	start := int(t.sa[iv.L])
+	return t.x[start : start+iv.Depth]
+}
+
+// Positions returns the text positions of the suffixes in iv, in suffix
+// array order.
+func (t *LCPIntervalTree) Positions(iv Interval) []int32 {
+	return t.sa[iv.L:iv.R]
+}
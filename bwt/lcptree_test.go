@@ -0,0 +1,91 @@
+package bwt
+
+import (
+	"reflect"
+	"testing"
+)
+
+// naiveChildren splits the rows [L, R) of sa, whose suffixes share depth
+// characters, by the character that follows, and gives each group the
+// length of its longest common prefix, like the edges of a suffix tree.
+func naiveChildren(x string, sa []int32, iv Interval) []Interval {
+	if iv.R-iv.L < 2 {
+		return nil
+	}
+	next := func(r int) int {
+		if i := int(sa[r]) + iv.Depth; i < len(x) {
+			return int(x[i])
+		}
+		return -1 // the sentinel
+	}
+	children := []Interval{}
+	for L := iv.L; L < iv.R; {
+		R := L + 1
+		for R < iv.R && next(R) == next(L) {
+			R++
+		}
+		depth := len(x) - int(sa[L])
+		if R-L > 1 {
+			a, b := x[sa[L]:], x[sa[R-1]:]
+			for depth = 0; depth < len(a) && depth < len(b) && a[depth] == b[depth]; depth++ {
+			}
+		}
+		children = append(children, Interval{L, R, depth})
+		L = R
+	}
+	return children
+}
+
+func TestLCPIntervalTree(t *testing.T) {
+	rng := newRandomSeed(t)
+	xs := []string{"", "a", "aaaa", "mississippi", "abab"}
+	for i := 0; i < 20; i++ {
+		xs = append(xs, randomStringN(rng.Intn(40), "acgt"[:1+rng.Intn(4)], rng))
+	}
+	for _, x := range xs {
+		tree := NewLCPIntervalTree(x)
+		sa := PrefixDoubling(x)
+		root := tree.Root()
+		if expected := (Interval{0, len(x) + 1, 0}); len(x) > 0 && root != expected {
+			t.Errorf("Expected root %v for %q, got %v", expected, x, root)
+		}
+		nodes := 0
+		var walk func(iv Interval)
+		walk = func(iv Interval) {
+			nodes++
+			got, expected := tree.Children(iv), naiveChildren(x, sa, iv)
+			if !reflect.DeepEqual(got, expected) {
+				t.Fatalf("Expected children %v of %v in %q, got %v", expected, iv, x, got)
+			}
+			for _, pos := range tree.Positions(iv) {
+				if x[pos:int(pos)+iv.Depth] != tree.Label(iv) {
+					t.Errorf("Expected %q at %d, got %q", tree.Label(iv), pos, x[pos:int(pos)+iv.Depth])
+				}
+			}
+			for _, child := range got {
+				walk(child)
+			}
+		}
+		walk(root)
+		if nodes > 2*len(sa) {
+			t.Errorf("Expected at most %d nodes for %q, got %d", 2*len(sa), x, nodes)
+		}
+	}
+
+	// The repeats of length at least 3 in mississippi.
+	tree := NewLCPIntervalTree("mississippi")
+	repeats := []string{}
+	var walk func(iv Interval)
+	walk = func(iv Interval) {
+		if iv.Count() > 1 && iv.Depth >= 3 {
+			repeats = append(repeats, tree.Label(iv))
+		}
+		for _, child := range tree.Children(iv) {
+			walk(child)
+		}
+	}
+	walk(tree.Root())
+	if expected := []string{"issi", "ssi"}; !reflect.DeepEqual(repeats, expected) {
+		t.Errorf("Expected repeats %v, got %v", expected, repeats)
+	}
+}
@@ -0,0 +1,34 @@
+package bwt
+
+// BuildLFArray computes the LF-mapping for every row of bwt, lf[i] =
+// C[a] + O(a, i) where a = bwt[i], so walks over the BWT can follow the
+// array instead of looking up ranks in the tables. The array takes O(n)
+// memory. The O-table has no row for the sentinel, so its ranks are
+// counted while the array is built. The array pays off for repeated walks
+// over an index that has the O-table anyway; a single walk, as in Rbwt,
+// computes the same mapping with a counting pass instead.
+func BuildLFArray(ctab *CTab, otab *OTab, bwt []byte) []int {
+	return buildLFArray(ctab, otab, bwt)
+}
+
+// buildLFArray is BuildLFArray for any O-table.
+func buildLFArray(ctab *CTab, otab rankTable, bwt []byte) []int {
+	lf := make([]int, len(bwt))
+	sentinels := 0
+	for i, a := range bwt {
+		if a == 0 {
+			lf[i] = ctab.Rank(0) + sentinels
+			sentinels++
+		} else {
+			lf[i] = ctab.Rank(a) + otab.Rank(a, i)
+		}
+	}
+	return lf
+}
+
+// UseLFArray precomputes the LF array for index, so locating positions
+// in a compact index and extracting from it follow the array instead of
+// looking up ranks. It costs one int per row of the BWT.
+func UseLFArray(index *Index) {
+	index.lfArray = buildLFArray(index.ctab, index.otab, index.bwt)
+}
@@ -0,0 +1,46 @@
+package bwt
+
+import "testing"
+
+func TestBuildLFArray(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, n := range []int{0, 1, 10, 100} {
+		x := randomStringN(n, "acgt", rng)
+		idx := BuildIndex(x)
+		lf := BuildLFArray(idx.ctab, idx.otab.(*OTab), idx.bwt)
+
+		// Walking the array from the sentinel's row, row 0, gives the
+		// text backwards and ends back at row 0.
+		y := make([]byte, n)
+		i := 0
+		for j := n - 1; j >= 0; j-- {
+			y[j] = idx.bwt[i]
+			i = lf[i]
+		}
+		if text, _ := idx.alpha.Unmap(y); text != x {
+			t.Errorf("Expected %q, got %q", x, text)
+		}
+		if lf[i] != 0 {
+			t.Errorf("Expected the walk of %q to end at row 0, got row %d", x, lf[i])
+		}
+	}
+}
+
+func TestUseLFArray(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(500, "acgt", rng)
+	full, compact := BuildIndex(x), BuildIndexCompact(x)
+	UseLFArray(compact)
+	for j := 0; j < 20; j++ {
+		p := randomStringN(1+rng.Intn(3), "acgt", rng)
+		positions, expected := compact.Locate(p), full.Locate(p)
+		for k := range expected {
+			if positions[k] != expected[k] {
+				t.Fatalf("Locate(%q) = %v, expected %v", p, positions, expected)
+			}
+		}
+	}
+	if y := compact.Extract(100, 200); y != x[100:200] {
+		t.Errorf("Expected %q, got %q", x[100:200], y)
+	}
+}
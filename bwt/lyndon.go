@@ -0,0 +1,164 @@
+package bwt
+
+// LyndonFactorization splits x into its Lyndon factorization: the unique
+// sequence of Lyndon words w1 >= w2 >= ... >= wk with x = w1w2...wk, where
+// a Lyndon word is strictly smaller than all its proper suffixes. It uses
+// Duval's algorithm, which runs in O(n) time.
+func LyndonFactorization(x string) []string {
+	factors := []string{}
+	n := len(x)
+	for i := 0; i < n; {
+		// x[i:k] is a repetition of the Lyndon word x[i:i+(k-j)]
+		// followed by a prefix of it.
+		j, k := i, i+1
+		for k < n && x[j] <= x[k] {
+			if x[j] < x[k] {
+				j = i
+			} else {
+				j++
+			}
+			k++
+		}
+		for i <= j {
+			factors = append(factors, x[i:i+k-j])
+			i += k - j
+		}
+	}
+	return factors
+}
+
+// BWTS computes the bijective Burrows-Wheeler transform of x, which needs
+// no sentinel, so x can contain any bytes, including zero, and the result
+// has the same length as x. The rotations of all the Lyndon factors of x
+// are sorted in omega order, comparing the infinite repetitions u^ω and
+// v^ω of rotations u and v, and the result is the last character of each
+// sorted rotation.
+//
+// The rotations are sorted by prefix doubling, as in PrefixDoubling, but
+// on the repetitions: the character k after a position wraps around to
+// the start of its factor. Two repetitions that agree on their first
+// |u|+|v| <= n characters are equal, so after O(log n) rounds of radix
+// sorting, the order is final, and BWTS runs in O(n log n) time.
+func BWTS(x string) string {
+	n := len(x)
+	if n == 0 {
+		return ""
+	}
+	// start[p] and length[p] are the start and length of the factor that
+	// holds position p.
+	start, length := make([]int, n), make([]int, n)
+	pos := 0
+	for _, w := range LyndonFactorization(x) {
+		for i := pos; i < pos+len(w); i++ {
+			start[i], length[i] = pos, len(w)
+		}
+		pos += len(w)
+	}
+	// next returns the position k characters after p in the repetition of
+	// its factor.
+	next := func(p, k int) int {
+		return start[p] + (p-start[p]+k)%length[p]
+	}
+
+	rank := make([]int, n)
+	for p := 0; p < n; p++ {
+		rank[p] = int(x[p])
+	}
+	rots, buf := make([]int, n), make([]int, n)
+	for p := range rots {
+		rots[p] = p
+	}
+	buckets := make([]int, n+256)
+	sortBy := func(src, dst []int, key func(p int) int) {
+		for b := range buckets {
+			buckets[b] = 0
+		}
+		for _, p := range src {
+			buckets[key(p)]++
+		}
+		acc := 0
+		for b, count := range buckets {
+			buckets[b] = acc
+			acc += count
+		}
+		for _, p := range src {
+			dst[buckets[key(p)]] = p
+			buckets[key(p)]++
+		}
+	}
+	sortBy(rots, buf, func(p int) int { return rank[p] })
+	rots, buf = buf, rots
+	newRank := make([]int, n)
+	for k := 1; k < n; k *= 2 {
+		// Sort by the rank k characters on, then stable sort by the rank
+		// at the rotation itself, and rank the pairs.
+		sortBy(rots, buf, func(p int) int { return rank[next(p, k)] })
+		sortBy(buf, rots, func(p int) int { return rank[p] })
+		sigma := 0
+		newRank[rots[0]] = 0
+		for i := 1; i < n; i++ {
+			p, q := rots[i-1], rots[i]
+			if rank[p] != rank[q] || rank[next(p, k)] != rank[next(q, k)] {
+				sigma++
+			}
+			newRank[q] = sigma
+		}
+		rank, newRank = newRank, rank
+		if sigma == n-1 {
+			break
+		}
+	}
+
+	y := make([]byte, n)
+	for i, p := range rots {
+		y[i] = x[next(p, length[p]-1)]
+	}
+	return string(y)
+}
+
+// InverseBWTS reverses BWTS, so InverseBWTS(BWTS(x)) == x. LF-mapping on
+// the transform splits the rows into cycles, one per Lyndon factor, and
+// the first row of each cycle is the factor itself, since a Lyndon word is
+// the smallest of its rotations. Walking each cycle from its first row
+// gives its factor backwards, and the factors, found in increasing order,
+// are concatenated in decreasing order. It runs in O(n) time.
+func InverseBWTS(y string) string {
+	n := len(y)
+	var ctab [256]int
+	for i := 0; i < n; i++ {
+		ctab[y[i]]++
+	}
+	acc := 0
+	for a, count := range ctab {
+		ctab[a] = acc
+		acc += count
+	}
+	lf := make([]int, n)
+	var seen [256]int
+	for i := 0; i < n; i++ {
+		lf[i] = ctab[y[i]] + seen[y[i]]
+		seen[y[i]]++
+	}
+
+	x := make([]byte, n)
+	end := n // the factors are written from the end of x
+	visited := make([]bool, n)
+	for i := 0; i < n; i++ {
+		if visited[i] {
+			continue
+		}
+		// Count the cycle, then write its factor backwards into place.
+		length := 0
+		for j := i; !visited[j]; j = lf[j] {
+			visited[j] = true
+			length++
+		}
+		k := end
+		for j, steps := i, 0; steps < length; j, steps = lf[j], steps+1 {
+			k--
+			x[k] = y[j]
+		}
+		end -= length
+	}
+	return string(x)
+}
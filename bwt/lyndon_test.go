@@ -0,0 +1,87 @@
+package bwt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// isLyndon reports whether w is strictly smaller than all its proper
+// suffixes.
+func isLyndon(w string) bool {
+	if w == "" {
+		return false
+	}
+	for i := 1; i < len(w); i++ {
+		if w[i:] <= w {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLyndonFactorization(t *testing.T) {
+	known := map[string][]string{
+		"":            {},
+		"a":           {"a"},
+		"ba":          {"b", "a"},
+		"banana":      {"b", "an", "an", "a"},
+		"aab":         {"aab"},
+		"abab":        {"ab", "ab"},
+		"mississippi": {"m", "iss", "iss", "ipp", "i"},
+	}
+	for x, expected := range known {
+		if factors := LyndonFactorization(x); !reflect.DeepEqual(factors, expected) {
+			t.Errorf("LyndonFactorization(%q) = %q, expected %q", x, factors, expected)
+		}
+	}
+
+	// The factorization is unique, so any nonincreasing sequence of
+	// Lyndon words that spells x is it.
+	rng := newRandomSeed(t)
+	for i := 0; i < 100; i++ {
+		x := randomStringN(rng.Intn(30), "abc", rng)
+		factors := LyndonFactorization(x)
+		if strings.Join(factors, "") != x {
+			t.Errorf("Expected the factors %q to spell %q", factors, x)
+		}
+		for k, w := range factors {
+			if !isLyndon(w) {
+				t.Errorf("Factor %q of %q is not a Lyndon word", w, x)
+			}
+			if k > 0 && factors[k-1] < w {
+				t.Errorf("Factors %q and %q of %q are increasing", factors[k-1], w, x)
+			}
+		}
+	}
+}
+
+func TestBWTS(t *testing.T) {
+	// SCOTTY factors into S and COTTY, and the rotations COTTY, OTTYC,
+	// S, TTYCO, TYCOT and YCOTT are in omega order.
+	if y := BWTS("SCOTTY"); y != "YCSOTT" {
+		t.Errorf("Expected BWTS(SCOTTY) = YCSOTT, got %q", y)
+	}
+
+	rng := newRandomSeed(t)
+	inputs := []string{"", "a", "aaaa", "banana", "abab", "\x00\x00\x01\x00", "mississippi"}
+	// A long, repetitive text is where comparing rotations would be slow.
+	inputs = append(inputs, strings.Repeat("ab", 20000)+"a")
+	for i := 0; i < 100; i++ {
+		inputs = append(inputs, randomStringN(rng.Intn(40), "ab\x00", rng))
+	}
+	for i := 0; i < 10; i++ {
+		b := make([]byte, rng.Intn(200))
+		rng.Read(b)
+		inputs = append(inputs, string(b))
+	}
+	for _, x := range inputs {
+		y := BWTS(x)
+		if len(y) != len(x) {
+			t.Errorf("Expected BWTS(%q) to have length %d, got %d", x, len(x), len(y))
+		}
+		if z := InverseBWTS(y); z != x {
+			t.Errorf("Expected InverseBWTS(BWTS(%q)) to give it back, got %q", x, z)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package bwt
+
+// MaskMode determines how BuildIndexMasked treats soft-masked bases, the
+// lowercase letters that reference genomes use for repeat regions.
+type MaskMode int
+
+const (
+	// MaskPreserve keeps lowercase letters as symbols of their own, so
+	// searches are case sensitive, as with BuildIndex.
+	MaskPreserve MaskMode = iota
+	// MaskFold folds the text to uppercase, and patterns match in
+	// either case.
+	MaskFold
+	// MaskExclude replaces every masked base with a separator that no
+	// pattern matches, so only occurrences entirely outside the masked
+	// regions are found. Positions are unchanged.
+	MaskExclude
+)
+
+// maskSeparator replaces masked bases with MaskExclude.
+const maskSeparator = '#'
+
+func isMasked(a byte) bool {
+	return 'a' <= a && a <= 'z'
+}
+
+func toUpper(a byte) byte {
+	if isMasked(a) {
+		return a - 'a' + 'A'
+	}
+	return a
+}
+
+// BuildIndexMasked builds the FM-index for x like BuildIndex, treating
+// lowercase letters in x according to mode. The indexed text, as returned
+// by Extract, is the folded text for MaskFold and has separators, '#', in
+// place of the masked bases for MaskExclude. With MaskExclude, any '#'
+// already in x is a separator too.
+func BuildIndexMasked(x string, mode MaskMode) *Index {
+	switch mode {
+	case MaskFold:
+		y := []byte(x)
+		for i, a := range y {
+			y[i] = toUpper(a)
+		}
+		idx := BuildIndex(string(y))
+		// Map lowercase letters to the symbols of their uppercase forms,
+		// so patterns are folded as they are searched.
+		for a := byte('a'); a <= 'z'; a++ {
+			idx.alpha.symbols[a] = idx.alpha.symbols[toUpper(a)]
+		}
+		return idx
+
+	case MaskExclude:
+		y := []byte(x)
+		for i, a := range y {
+			if isMasked(a) {
+				y[i] = maskSeparator
+			}
+		}
+		idx := BuildIndex(string(y))
+		// The separator stays in the BWT but no pattern character maps
+		// to it, so searches cannot cross into a masked region.
+		idx.alpha.symbols[maskSeparator] = 0
+		return idx
+
+	default:
+		return BuildIndex(x)
+	}
+}
+
+// CountMasked counts the occurrences of p that overlap no masked position,
+// where masked reports whether a text position is masked. Unlike
+// BuildIndexMasked, the mask is applied at query time, so one index can
+// serve different masks. Each occurrence is located and its span checked,
+// so the query costs O(occ*m) calls of masked.
+func CountMasked(index *Index, p string, masked func(pos int32) bool) int {
+	return countMasked(index, p, masked)
+}
+
+// CountMaskedBytes is CountMasked for a pattern given as a byte slice.
+func CountMaskedBytes(index *Index, p []byte, masked func(pos int32) bool) int {
+	return countMasked(index, p, masked)
+}
+
+func countMasked[P pattern](index *Index, p P, masked func(pos int32) bool) int {
+	count := 0
+occurrences:
+	for _, pos := range index.positions(search(index, p)) {
+		for i := pos; i < pos+int32(len(p)); i++ {
+			if masked(i) {
+				continue occurrences
+			}
+		}
+		count++
+	}
+	return count
+}
@@ -0,0 +1,72 @@
+package bwt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildIndexMasked(t *testing.T) {
+	x := "ACGTacgtACGT"
+	tests := []struct {
+		mode     MaskMode
+		text     string
+		patterns map[string][]int32
+	}{
+		{MaskPreserve, x, map[string][]int32{
+			"ACG": {0, 8}, "acg": {4}, "GTac": {2}, "Acg": {},
+		}},
+		{MaskFold, "ACGTACGTACGT", map[string][]int32{
+			"ACG": {0, 4, 8}, "acg": {0, 4, 8}, "GTac": {2, 6}, "Acg": {0, 4, 8},
+		}},
+		{MaskExclude, "ACGT####ACGT", map[string][]int32{
+			"ACG": {0, 8}, "acg": {}, "GTac": {}, "T#": {}, "GT": {2, 10},
+		}},
+	}
+	for _, test := range tests {
+		idx := BuildIndexMasked(x, test.mode)
+		if text := idx.Extract(0, idx.Len()); text != test.text {
+			t.Errorf("Mode %d: expected the text %q, got %q", test.mode, test.text, text)
+		}
+		for p, expected := range test.patterns {
+			if positions := sortedLocate(idx, p); !reflect.DeepEqual(positions, expected) {
+				t.Errorf("Mode %d: expected %q at %v, got %v", test.mode, p, expected, positions)
+			}
+			if count := idx.Count(p); count != len(expected) {
+				t.Errorf("Mode %d: expected %d occurrences of %q, got %d", test.mode, len(expected), p, count)
+			}
+		}
+	}
+}
+
+func TestCountMasked(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(500, "acgt", rng)
+	idx := BuildIndex(x)
+	for _, period := range []int32{3, 7, 50} {
+		masked := func(pos int32) bool { return pos%period == 0 }
+		for i := 0; i < 20; i++ {
+			p := randomStringN(1+rng.Intn(4), "acgt", rng)
+			expected := 0
+			for _, pos := range naiveOccurrences(x, p) {
+				clear := true
+				for j := pos; j < pos+int32(len(p)); j++ {
+					clear = clear && !masked(j)
+				}
+				if clear {
+					expected++
+				}
+			}
+			if count := CountMasked(idx, p, masked); count != expected {
+				t.Errorf("Expected %d unmasked occurrences of %q, got %d", expected, p, count)
+			}
+			if count := CountMaskedBytes(idx, []byte(p), masked); count != expected {
+				t.Errorf("Expected %d unmasked occurrences of %q as bytes, got %d", expected, p, count)
+			}
+		}
+	}
+
+	none := func(int32) bool { return false }
+	if count := CountMasked(idx, "ac", none); count != idx.Count("ac") {
+		t.Errorf("Expected all %d occurrences without a mask, got %d", idx.Count("ac"), count)
+	}
+}
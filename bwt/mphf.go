@@ -0,0 +1,173 @@
+package bwt
+
+// KmerMPHF is a minimal perfect hash function over the distinct k-mers of
+// an indexed text: it maps each of the Len() k-mers to a distinct id in
+// [0, Len()).
+//
+// The function is built with the hash-and-displace scheme. Keys are
+// hashed into buckets, and the buckets, largest first, each get a seed
+// that places all their keys in free slots. A lookup is then two hashes
+// and a table access. To reject strings that are not in the key set, each
+// slot also holds a 32-bit fingerprint of its key, so an absent string is
+// wrongly accepted with probability about 2^-32.
+type KmerMPHF struct {
+	k       int
+	salt    uint64
+	seeds   []uint32
+	fprints []uint32
+}
+
+// mphfLoad is the average number of keys per bucket.
+const mphfLoad = 4
+
+// mix is the splitmix64 finalizer.
+func mix(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// baseHash is the salted 64-bit FNV-1a hash of key, finalized with mix.
+// FNV-1a alone barely changes the high bits for short keys, which would
+// put all the k-mers of a small k in one or two buckets.
+func baseHash[P pattern](key P, salt uint64) uint64 {
+	h := uint64(14695981039346656037) ^ mix(salt)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return mix(h)
+}
+
+func (f *KmerMPHF) bucket(h uint64) int {
+	return int((h >> 32) % uint64(len(f.seeds)))
+}
+
+func (f *KmerMPHF) slot(h uint64, seed uint32) int {
+	return int(mix(h^(uint64(seed)*0x9e3779b97f4a7c15)) % uint64(len(f.fprints)))
+}
+
+func fingerprint(h uint64) uint32 {
+	return uint32(mix(h + 1))
+}
+
+// BuildKmerMPHF builds a minimal perfect hash function over the distinct
+// k-mers in the indexed text. A negative k gives a hash function over no
+// k-mers.
+func BuildKmerMPHF(index *Index, k int) *KmerMPHF {
+	keys := []string{}
+	forEachKmer(index, k, func(kmer string, L, R int) {
+		keys = append(keys, kmer)
+	})
+
+	// A failure means that two keys collided in the base hash, or that a
+	// bucket ran out of seeds; both are unlikely, and a new salt fixes it.
+	for salt := uint64(0); ; salt++ {
+		if f, ok := buildMPHF(keys, k, salt); ok {
+			return f
+		}
+	}
+}
+
+// maxSeed bounds the search for a bucket's seed before giving up on a salt.
+const maxSeed = 1 << 16
+
+func buildMPHF(keys []string, k int, salt uint64) (*KmerMPHF, bool) {
+	f := &KmerMPHF{
+		k:       k,
+		salt:    salt,
+		seeds:   make([]uint32, len(keys)/mphfLoad+1),
+		fprints: make([]uint32, len(keys)),
+	}
+
+	buckets := make([][]uint64, len(f.seeds))
+	for _, key := range keys {
+		h := baseHash(key, salt)
+		b := f.bucket(h)
+		buckets[b] = append(buckets[b], h)
+	}
+
+	// Placing the large buckets first, while most slots are free, keeps
+	// the seeds small. A counting sort on bucket size orders them.
+	bySize := make([][]int, mphfLoad*4)
+	for b, hs := range buckets {
+		size := len(hs)
+		if size >= len(bySize) {
+			size = len(bySize) - 1
+		}
+		bySize[size] = append(bySize[size], b)
+	}
+
+	taken := make([]bool, len(keys))
+	slots := []int{}
+	for size := len(bySize) - 1; size > 0; size-- {
+		for _, b := range bySize[size] {
+			seed := uint32(0)
+			for ; seed < maxSeed; seed++ {
+				if f.place(buckets[b], seed, taken, &slots) {
+					break
+				}
+			}
+			if seed == maxSeed {
+				return nil, false
+			}
+			f.seeds[b] = seed
+			for i, s := range slots {
+				taken[s] = true
+				f.fprints[s] = fingerprint(buckets[b][i])
+			}
+		}
+	}
+	return f, true
+}
+
+// place computes the slots for the hashes hs with the given seed into
+// slots, and reports whether they are all free and distinct.
+func (f *KmerMPHF) place(hs []uint64, seed uint32, taken []bool, slots *[]int) bool {
+	*slots = (*slots)[:0]
+	for _, h := range hs {
+		s := f.slot(h, seed)
+		if taken[s] {
+			return false
+		}
+		for _, t := range *slots {
+			if s == t {
+				return false
+			}
+		}
+		*slots = append(*slots, s)
+	}
+	return true
+}
+
+// Len returns the number of k-mers in the hash function.
+func (f *KmerMPHF) Len() int {
+	return len(f.fprints)
+}
+
+// Lookup returns the id of kmer, and false if kmer is not one of the
+// k-mers the function was built over. See KmerMPHF for the false-positive
+// rate.
+func (f *KmerMPHF) Lookup(kmer string) (int, bool) {
+	return lookup(f, kmer)
+}
+
+// LookupBytes is Lookup for a k-mer given as a byte slice.
+func (f *KmerMPHF) LookupBytes(kmer []byte) (int, bool) {
+	return lookup(f, kmer)
+}
+
+func lookup[P pattern](f *KmerMPHF, kmer P) (int, bool) {
+	if len(kmer) != f.k || len(f.fprints) == 0 {
+		return 0, false
+	}
+	h := baseHash(kmer, f.salt)
+	s := f.slot(h, f.seeds[f.bucket(h)])
+	if f.fprints[s] != fingerprint(h) {
+		return 0, false
+	}
+	return s, true
+}
@@ -0,0 +1,50 @@
+package bwt
+
+import (
+	"testing"
+)
+
+func TestKmerMPHF(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, k := range []int{1, 3, 6} {
+		x := randomStringN(500, "acgt", rng)
+		f := BuildKmerMPHF(BuildIndex(x), k)
+
+		kmers := map[string]bool{}
+		for i := 0; i+k <= len(x); i++ {
+			kmers[x[i:i+k]] = true
+		}
+		if f.Len() != len(kmers) {
+			t.Fatalf("Expected %d k-mers, got %d", len(kmers), f.Len())
+		}
+
+		ids := make([]bool, f.Len())
+		for kmer := range kmers {
+			id, ok := f.Lookup(kmer)
+			if !ok || id < 0 || id >= f.Len() {
+				t.Fatalf("Lookup(%q) = %d, %t", kmer, id, ok)
+			}
+			if bid, ok := f.LookupBytes([]byte(kmer)); !ok || bid != id {
+				t.Fatalf("LookupBytes(%q) = %d, %t, expected %d", kmer, bid, ok, id)
+			}
+			if ids[id] {
+				t.Fatalf("Id %d is used twice", id)
+			}
+			ids[id] = true
+		}
+
+		for _, absent := range []string{randomStringN(k, "n", rng), randomStringN(k+1, "acgt", rng)} {
+			if _, ok := f.Lookup(absent); ok {
+				t.Errorf("Expected %q to be rejected", absent)
+			}
+		}
+	}
+
+	f := BuildKmerMPHF(BuildIndex(""), 2)
+	if _, ok := f.Lookup("aa"); ok || f.Len() != 0 {
+		t.Errorf("Expected an empty hash function for an empty text")
+	}
+	if f := BuildKmerMPHF(BuildIndex("acgt"), -1); f.Len() != 0 {
+		t.Errorf("Expected no k-mers for a negative k, got %d", f.Len())
+	}
+}
@@ -0,0 +1,125 @@
+package bwt
+
+// MS is a matching statistic: the length of the longest prefix of a
+// pattern suffix that occurs in the indexed text, and a text position
+// where it occurs. Pos is -1 when Len is zero.
+type MS struct {
+	Len int
+	Pos int32
+}
+
+// MatchingStatistics returns, for each position i in p, the length of the
+// longest prefix of p[i:] that occurs in the indexed text together with
+// the position of one of its occurrences.
+//
+// The pattern is processed from right to left, using that the match at i
+// is at most one longer than the match at i+1. The match at i+1 is
+// extended with p[i] by backward search, and when that fails, the match
+// is shortened from the right, like following a suffix link in the
+// reverse text, until it can be extended. Shortening jumps straight to
+// the parent of the match's interval in the suffix tree, the longest
+// prefix of the match whose interval is larger, since the prefixes in
+// between have the same interval and cannot be extended either. The
+// parent is found in O(1) time from the LCP array and its previous- and
+// next-smaller values, and each shortening undoes at least one earlier
+// extension, so the running time is O(m) rank queries for a pattern of
+// length m. The arrays take 3n int32s and are built on the first call
+// and kept with the index.
+func MatchingStatistics(index *Index, p string) []MS {
+	return matchingStatistics(index, p)
+}
+
+// MatchingStatisticsBytes is MatchingStatistics for a pattern given as a
+// byte slice.
+func MatchingStatisticsBytes(index *Index, p []byte) []MS {
+	return matchingStatistics(index, p)
+}
+
+func matchingStatistics[P pattern](index *Index, p P) []MS {
+	index.intervalsOnce.Do(func() {
+		index.intervals = newLcpIntervals(Lcp(index.Extract(0, index.Len()), index.positions(0, len(index.bwt))))
+	})
+	ms := make([]MS, len(p))
+	n := len(index.bwt)
+
+	// The current match is p[i+1:i+1+length] with interval [L, R).
+	length, L, R := 0, 0, n
+	for i := len(p) - 1; i >= 0; i-- {
+		for {
+			if l, r := index.extend(p[i], L, R); l < r {
+				length, L, R = length+1, l, r
+				break
+			}
+			if length == 0 {
+				break // p[i] does not occur in the text
+			}
+			length, L, R = index.intervals.parent(L, R)
+		}
+
+		if length == 0 {
+			ms[i] = MS{Len: 0, Pos: -1}
+		} else {
+			ms[i] = MS{Len: length, Pos: index.position(L)}
+		}
+	}
+	return ms
+}
+
+// lcpIntervals finds the parents of suffix-array intervals in the suffix
+// tree. lcp has an entry for every row and one past the last, with -1 at
+// both ends, and psv[k] and nsv[k] are the closest rows before and after k
+// whose lcp is smaller than lcp[k].
+type lcpIntervals struct {
+	lcp, psv, nsv []int32
+}
+
+// newLcpIntervals builds the parent structure from the LCP array of the
+// suffix array, as from Lcp.
+func newLcpIntervals(lcp []int32) *lcpIntervals {
+	n := len(lcp)
+	t := &lcpIntervals{
+		lcp: append(append([]int32{-1}, lcp[1:]...), -1),
+		psv: make([]int32, n+1),
+		nsv: make([]int32, n+1),
+	}
+	stack := []int32{}
+	for k := range t.lcp {
+		for len(stack) > 0 && t.lcp[stack[len(stack)-1]] >= t.lcp[k] {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			t.psv[k] = stack[len(stack)-1]
+		}
+		stack = append(stack, int32(k))
+	}
+	stack = stack[:0]
+	for k := len(t.lcp) - 1; k >= 0; k-- {
+		for len(stack) > 0 && t.lcp[stack[len(stack)-1]] >= t.lcp[k] {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			t.nsv[k] = stack[len(stack)-1]
+		} else {
+			t.nsv[k] = int32(n)
+		}
+		stack = append(stack, int32(k))
+	}
+	return t
+}
+
+// parent returns the length and interval of the parent of the interval
+// [L, R) of a non-empty string: the longest prefix of the string whose
+// interval is larger. The prefix's length is the larger of the lcp values
+// at the interval's boundaries, and its interval extends from the
+// boundary with that value to the closest rows on either side with a
+// smaller one.
+func (t *lcpIntervals) parent(L, R int) (length, l, r int) {
+	k := L
+	if t.lcp[R] > t.lcp[L] {
+		k = R
+	}
+	if t.lcp[k] <= 0 {
+		return 0, 0, len(t.lcp) - 1
+	}
+	return int(t.lcp[k]), int(t.psv[k]), int(t.nsv[k])
+}
@@ -0,0 +1,63 @@
+package bwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchingStatistics(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := randomStringN(30, "acgt", rng)
+		p := randomStringN(15, "acgtn", rng) // n never matches
+		idx := BuildIndex(x)
+		ms := MatchingStatistics(idx, p)
+		if len(ms) != len(p) {
+			t.Fatalf("Expected %d matching statistics, got %d", len(p), len(ms))
+		}
+
+		for j := range p {
+			expected := 0
+			for l := 1; j+l <= len(p); l++ {
+				if strings.Contains(x, p[j:j+l]) {
+					expected = l
+				}
+			}
+			if ms[j].Len != expected {
+				t.Errorf("MS[%d] for %q in %q: length %d, expected %d",
+					j, p, x, ms[j].Len, expected)
+				continue
+			}
+			if expected == 0 {
+				if ms[j].Pos != -1 {
+					t.Errorf("Expected position -1 for an empty match, got %d", ms[j].Pos)
+				}
+			} else if x[ms[j].Pos:int(ms[j].Pos)+expected] != p[j:j+expected] {
+				t.Errorf("MS[%d] for %q in %q: no match at position %d",
+					j, p, x, ms[j].Pos)
+			}
+		}
+	}
+}
+
+func TestMatchingStatisticsCompact(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		// Repeats give deep intervals, so the matches are shortened
+		// through several parents.
+		x := strings.Repeat(randomStringN(5, "acgt", rng), 10) + randomStringN(20, "acgt", rng)
+		p := randomStringN(40, "acgt", rng)
+		ms := MatchingStatistics(BuildIndexCompact(x), p)
+		for j := range p {
+			expected := 0
+			for expected < len(p)-j && strings.Contains(x, p[j:j+expected+1]) {
+				expected++
+			}
+			if ms[j].Len != expected {
+				t.Errorf("MS[%d] for %q in %q: length %d in the compact index, expected %d", j, p, x, ms[j].Len, expected)
+			} else if expected > 0 && x[ms[j].Pos:int(ms[j].Pos)+expected] != p[j:j+expected] {
+				t.Errorf("MS[%d] for %q in %q: no match at position %d", j, p, x, ms[j].Pos)
+			}
+		}
+	}
+}
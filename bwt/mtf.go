@@ -0,0 +1,44 @@
+package bwt
+
+// mtfList is the initial move-to-front list: all bytes in order.
+func mtfList() []byte {
+	list := make([]byte, 256)
+	for i := range list {
+		list[i] = byte(i)
+	}
+	return list
+}
+
+// BwtRanks returns the move-to-front encoding of the BWT of x: for each
+// position in the BWT, the position of its character in a list of all
+// bytes, initially in order, where each character is moved to the front
+// after it is encoded. Runs in the BWT become runs of zeros, which is what
+// makes the output compress well with an entropy coder.
+func BwtRanks(x string) []int {
+	y := bwtFromSA(x, PrefixDoubling(x))
+	list := mtfList()
+	ranks := make([]int, len(y))
+	for i, a := range y {
+		r := 0
+		for list[r] != a {
+			r++
+		}
+		copy(list[1:r+1], list[:r])
+		list[0] = a
+		ranks[i] = r
+	}
+	return ranks
+}
+
+// inverseMTF decodes move-to-front ranks as computed by BwtRanks.
+func inverseMTF(ranks []int) []byte {
+	list := mtfList()
+	y := make([]byte, len(ranks))
+	for i, r := range ranks {
+		a := list[r]
+		copy(list[1:r+1], list[:r])
+		list[0] = a
+		y[i] = a
+	}
+	return y
+}
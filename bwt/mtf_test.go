@@ -0,0 +1,32 @@
+package bwt
+
+import (
+	"testing"
+)
+
+func TestBwtRanks(t *testing.T) {
+	rng := newRandomSeed(t)
+	inputs := []string{"", "aaaaaaaa", "mississippi"}
+	for i := 0; i < 10; i++ {
+		inputs = append(inputs, randomStringN(rng.Intn(100), "acgt", rng))
+	}
+	for _, x := range inputs {
+		ranks := BwtRanks(x)
+		if len(ranks) != len(x)+1 {
+			t.Fatalf("Expected %d ranks, got %d", len(x)+1, len(ranks))
+		}
+		if y := Rbwt(string(inverseMTF(ranks))); y != x {
+			t.Errorf("Expected %q back, got %q", x, y)
+		}
+	}
+
+	// The BWT of aaaa is aaaa followed by the sentinel, so only the first
+	// a and the sentinel have non-zero ranks.
+	ranks := BwtRanks("aaaa")
+	expected := []int{'a', 0, 0, 0, 1}
+	for i := range expected {
+		if ranks[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, ranks)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package bwt
+
+// OnlineIndex is a substring index over a text that grows one character
+// at a time. It is the suffix automaton of the text: the smallest
+// automaton that accepts exactly its substrings. Each state is a class of
+// substrings that end at the same set of positions, and its suffix link
+// goes to the class of its longest suffix that ends at more positions.
+// The suffix links form the suffix tree of the reversed text, and the
+// transitions are its Weiner links. Extending the text only follows suffix links from the state
+// of the whole text, so the automaton is built in amortised constant time
+// per character, and Contains runs in O(m) for a pattern of length m.
+//
+// Unlike the FM-index, nothing has to be rebuilt when the text grows, but
+// the automaton has up to 2n states and 3n transitions, so it uses much
+// more memory than a BWT.
+type OnlineIndex struct {
+	n      int
+	last   int32 // the state of the whole text
+	states []onlineState
+}
+
+type onlineState struct {
+	len  int32 // the length of the longest string in the state
+	link int32 // the suffix link, -1 for the initial state
+	next map[byte]int32
+}
+
+// NewOnlineIndex returns an index over the empty text.
+func NewOnlineIndex() *OnlineIndex {
+	return &OnlineIndex{
+		states: []onlineState{{link: -1, next: map[byte]int32{}}},
+	}
+}
+
+// Len returns the length of the text indexed so far.
+func (idx *OnlineIndex) Len() int {
+	return idx.n
+}
+
+// newState adds a state and returns its number.
+func (idx *OnlineIndex) newState(length, link int32, next map[byte]int32) int32 {
+	idx.states = append(idx.states, onlineState{len: length, link: link, next: next})
+	return int32(len(idx.states) - 1)
+}
+
+// Extend appends c to the text.
+func (idx *OnlineIndex) Extend(c byte) {
+	cur := idx.newState(idx.states[idx.last].len+1, 0, map[byte]int32{})
+	p := idx.last
+	for p >= 0 {
+		if _, ok := idx.states[p].next[c]; ok {
+			break
+		}
+		idx.states[p].next[c] = cur
+		p = idx.states[p].link
+	}
+	if p >= 0 {
+		q := idx.states[p].next[c]
+		if idx.states[p].len+1 == idx.states[q].len {
+			idx.states[cur].link = q
+		} else {
+			// q holds strings that are longer than the suffix of the
+			// text that reaches it from p, and they do not end at the
+			// new position, so split them off from q.
+			next := make(map[byte]int32, len(idx.states[q].next))
+			for a, s := range idx.states[q].next {
+				next[a] = s
+			}
+			clone := idx.newState(idx.states[p].len+1, idx.states[q].link, next)
+			for p >= 0 && idx.states[p].next[c] == q {
+				idx.states[p].next[c] = clone
+				p = idx.states[p].link
+			}
+			idx.states[q].link = clone
+			idx.states[cur].link = clone
+		}
+	}
+	idx.last = cur
+	idx.n++
+}
+
+// ExtendString appends all the characters of x to the text.
+func (idx *OnlineIndex) ExtendString(x string) {
+	for i := 0; i < len(x); i++ {
+		idx.Extend(x[i])
+	}
+}
+
+// Contains reports whether p is a substring of the text indexed so far.
+func (idx *OnlineIndex) Contains(p string) bool {
+	return onlineContains(idx, p)
+}
+
+// ContainsBytes is Contains for a pattern given as a byte slice.
+func (idx *OnlineIndex) ContainsBytes(p []byte) bool {
+	return onlineContains(idx, p)
+}
+
+func onlineContains[P pattern](idx *OnlineIndex, p P) bool {
+	state := int32(0)
+	for i := 0; i < len(p); i++ {
+		next, ok := idx.states[state].next[p[i]]
+		if !ok {
+			return false
+		}
+		state = next
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package bwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOnlineIndex(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, alpha := range []string{"a", "ab", "acgt"} {
+		x := randomStringN(60, alpha, rng)
+		idx := NewOnlineIndex()
+		for i := 0; i <= len(x); i++ {
+			if i > 0 {
+				idx.Extend(x[i-1])
+			}
+			if idx.Len() != i {
+				t.Fatalf("Expected length %d, got %d", i, idx.Len())
+			}
+			prefix := x[:i]
+			for trial := 0; trial < 20; trial++ {
+				p := randomStringN(rng.Intn(6), alpha, rng)
+				if trial%2 == 0 && i > 0 {
+					// Also query patterns known to occur.
+					start := rng.Intn(i)
+					p = prefix[start : start+rng.Intn(i-start+1)]
+				}
+				expected := strings.Contains(prefix, p)
+				if got := idx.Contains(p); got != expected {
+					t.Errorf("Expected Contains(%q) = %v on %q, got %v", p, expected, prefix, got)
+				}
+				if got := idx.ContainsBytes([]byte(p)); got != expected {
+					t.Errorf("Expected ContainsBytes(%q) = %v on %q, got %v", p, expected, prefix, got)
+				}
+			}
+		}
+		if len(idx.states) > 2*len(x) {
+			t.Errorf("Expected at most %d states, got %d", 2*len(x), len(idx.states))
+		}
+	}
+
+	idx := NewOnlineIndex()
+	idx.ExtendString("mississippi")
+	for _, p := range []string{"", "ssi", "issip", "mississippi"} {
+		if !idx.Contains(p) {
+			t.Errorf("Expected %q in mississippi", p)
+		}
+	}
+	for _, p := range []string{"spi", "pp i", "mississippis"} {
+		if idx.Contains(p) {
+			t.Errorf("Expected %q not in mississippi", p)
+		}
+	}
+}
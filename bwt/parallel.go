@@ -0,0 +1,189 @@
+package bwt
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// parallelMinWork is the number of suffixes below which an iteration of
+// PrefixDoublingParallel is not split between goroutines.
+const parallelMinWork = 1 << 14
+
+// saGroup is a range sa[start:end] of suffixes that share a rank.
+type saGroup struct {
+	start, end int32
+}
+
+// PrefixDoublingParallel computes the same suffix array as PrefixDoubling,
+// refining the buckets of suffixes concurrently.
+//
+// A bucket is a run of suffixes in the suffix array that agree on their
+// first k characters. Each suffix's rank is the start of its bucket, and
+// a doubling iteration sorts each bucket by the ranks of the suffixes k
+// positions further on. Those ranks usually belong to other buckets, so
+// buckets cannot be refined completely independently, but within an
+// iteration they only read the ranks from the previous one, so the buckets
+// can be sorted concurrently as long as the new ranks go to a separate
+// array. The buckets are split into contiguous shares, one per goroutine,
+// and an iteration with little work left runs serially.
+func PrefixDoublingParallel(x string) []int32 {
+	rank0, sigma := calcRank0[int32](x)
+	n := int32(len(rank0))
+
+	// Sort by the first character with a counting sort, and let each
+	// suffix's rank be the start of its bucket.
+	starts := make([]int32, sigma+1)
+	for _, r := range rank0 {
+		starts[r+1]++
+	}
+	for a := int32(1); a <= sigma; a++ {
+		starts[a] += starts[a-1]
+	}
+	sa := make([]int32, n)
+	rank := make([]int32, n)
+	next := append([]int32{}, starts...)
+	for i, r := range rank0 {
+		sa[next[r]] = int32(i)
+		next[r]++
+		rank[i] = starts[r]
+	}
+	newRank := make([]int32, n)
+
+	workers := runtime.GOMAXPROCS(0)
+	for k := int32(1); ; k *= 2 {
+		groups, work := []saGroup{}, int32(0)
+		for start := int32(0); start < n; {
+			end := start + 1
+			for end < n && rank[sa[end]] == rank[sa[start]] {
+				end++
+			}
+			if end-start > 1 {
+				groups = append(groups, saGroup{start, end})
+				work += end - start
+			}
+			start = end
+		}
+		if len(groups) == 0 {
+			return sa
+		}
+
+		copy(newRank, rank)
+		shares := 1
+		if work >= parallelMinWork {
+			shares = workers
+		}
+		var wg sync.WaitGroup
+		for _, share := range splitGroups(groups, work, shares) {
+			wg.Add(1)
+			go func(share []saGroup) {
+				defer wg.Done()
+				for _, g := range share {
+					refineGroup(sa[g.start:g.end], g.start, rank, newRank, k)
+				}
+			}(share)
+		}
+		wg.Wait()
+		rank, newRank = newRank, rank
+	}
+}
+
+// splitGroups splits groups into at most shares contiguous shares of
+// roughly work/shares suffixes each.
+func splitGroups(groups []saGroup, work int32, shares int) [][]saGroup {
+	split := [][]saGroup{}
+	target := work/int32(shares) + 1
+	from, acc := 0, int32(0)
+	for i, g := range groups {
+		acc += g.end - g.start
+		if acc >= target || i == len(groups)-1 {
+			split = append(split, groups[from:i+1])
+			from, acc = i+1, 0
+		}
+	}
+	return split
+}
+
+// refineGroup sorts the bucket of suffixes that starts at offset in the
+// suffix array by the ranks k positions on, and gives each new sub-bucket
+// the rank of its start. It only reads rank and only writes the ranks of
+// the bucket's own suffixes in newRank.
+func refineGroup(group []int32, offset int32, rank, newRank []int32, k int32) {
+	key := func(i int32) int32 { return getRank(rank, i+k) }
+	sort.Slice(group, func(a, b int) bool { return key(group[a]) < key(group[b]) })
+
+	head := offset
+	for j, i := range group {
+		if j > 0 && key(i) != key(group[j-1]) {
+			head = offset + int32(j)
+		}
+		newRank[i] = head
+	}
+}
+
+// RbwtParallel reconstructs the indexed text from the index's BWT, like
+// Rbwt, but splits the LF-walk into segments reconstructed concurrently.
+// Each segment ends at an anchor, a text position with a known row: the
+// suffix array gives the rows of evenly spaced positions, and a compact
+// index has them in its inverse suffix-array samples. Walking backwards
+// from one anchor to the previous reconstructs the text between them
+// independently of the other segments. With a single segment, or a
+// single goroutine to run them on, the walk is serial, without anchors.
+func RbwtParallel(index *Index) string {
+	n := index.Len()
+	y := make([]byte, n)
+	// walk reconstructs y[start:end] backwards from the row of the suffix
+	// at end.
+	walk := func(start, end, row int) {
+		for pos := end - 1; pos >= start; pos-- {
+			row, y[pos] = index.lf(row)
+		}
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	chunk := (n + workers - 1) / workers
+	if chunk < parallelMinWork {
+		chunk = parallelMinWork
+	}
+	segments := (n + chunk - 1) / chunk
+	if workers == 1 || segments <= 1 {
+		walk(0, n, 0)
+		x, _ := index.alpha.Unmap(y)
+		return x
+	}
+
+	// anchors[k] is the row of the suffix at (k+1)*chunk, the end of
+	// segment k; the last segment ends at the sentinel's row, 0.
+	anchors := make([]int, segments)
+	if s := index.samples; s != nil {
+		chunk = (chunk + s.rate - 1) / s.rate * s.rate
+		segments = (n + chunk - 1) / chunk
+		anchors = anchors[:segments]
+		for k := 0; k < segments-1; k++ {
+			anchors[k] = int(s.isa[(k+1)*chunk/s.rate])
+		}
+	} else {
+		for row, pos := range index.sa {
+			if k := int(pos)/chunk - 1; int(pos)%chunk == 0 && k >= 0 && k < segments-1 {
+				anchors[k] = row
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for k := range anchors {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			end := (k + 1) * chunk
+			if end > n {
+				end = n
+			}
+			walk(k*chunk, end, anchors[k])
+		}(k)
+	}
+	wg.Wait()
+
+	x, _ := index.alpha.Unmap(y)
+	return x
+}
@@ -0,0 +1,86 @@
+package bwt
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPrefixDoublingParallel(t *testing.T) {
+	rng := newRandomSeed(t)
+	inputs := []string{"", "a", "mississippi", strings.Repeat("ab", 1000)}
+	for i := 0; i < 5; i++ {
+		inputs = append(inputs, randomStringN(rng.Intn(100), "acgt", rng))
+	}
+	// Large enough to split the work between goroutines
+	inputs = append(inputs, randomStringN(4*parallelMinWork, "acgt", rng))
+
+	for _, x := range inputs {
+		sa, expected := PrefixDoublingParallel(x), PrefixDoubling(x)
+		if len(sa) != len(expected) {
+			t.Fatalf("Expected length %d, got %d", len(expected), len(sa))
+		}
+		for i := range sa {
+			if sa[i] != expected[i] {
+				t.Fatalf("Suffix arrays of a length %d string differ at %d", len(x), i)
+			}
+		}
+	}
+}
+
+func BenchmarkPrefixDoubling(b *testing.B) {
+	rng := newRandomSeed(b)
+	x := randomStringN(1<<20, "acgt", rng)
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			PrefixDoubling(x)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			PrefixDoublingParallel(x)
+		}
+	})
+}
+
+func TestRbwtParallel(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, n := range []int{0, 1, 100, parallelMinWork - 1, parallelMinWork, 3*parallelMinWork + 17} {
+		x := randomStringN(n, "acgt", rng)
+		full := BuildIndex(x)
+		if y := RbwtParallel(full); y != x {
+			t.Errorf("Expected RbwtParallel to give the text of length %d back", n)
+		}
+		if RbwtParallel(full) != Rbwt(Bwt(x)) {
+			t.Errorf("Expected RbwtParallel to match Rbwt for length %d", n)
+		}
+		if y := RbwtParallel(BuildIndexCompact(x)); y != x {
+			t.Errorf("Expected RbwtParallel on a compact index to give the text of length %d back", n)
+		}
+	}
+
+	// A single goroutine takes the serial walk.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	x := randomStringN(3*parallelMinWork, "acgt", rng)
+	if y := RbwtParallel(BuildIndexCompact(x)); y != x {
+		t.Errorf("Expected RbwtParallel with one goroutine to give the text back")
+	}
+}
+
+func BenchmarkRbwt(b *testing.B) {
+	rng := newRandomSeed(b)
+	x := randomStringN(1<<20, "acgt", rng)
+	idx := BuildIndex(x)
+	b.Run("serial", func(b *testing.B) {
+		// Rbwt over the index's mapped alphabet, so the tables are the
+		// same size as the index's.
+		for i := 0; i < b.N; i++ {
+			reverseBwt(idx.bwt, idx.alpha.Size())
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			RbwtParallel(idx)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package bwt
 
 import (
 	"math/rand"
+	"sort"
 	"testing"
 	"time"
 )
@@ -23,3 +24,41 @@ func randomStringN(n int, alpha string, rng *rand.Rand) string {
 
 	return string(bytes)
 }
+
+// randomStringWithMotifs constructs a random string of length n over the
+// alphabet alpha with the motifs planted at random positions, and returns
+// it together with the positions where each motif was planted. Motifs
+// listed more than once are planted more than once. The planted motifs do
+// not overlap and are separated by at least one background character, but
+// motifs can still occur by chance in the background or across a plant's
+// boundary; motifs with characters outside alpha only occur where they
+// were planted.
+func randomStringWithMotifs(n int, alpha string, motifs []string, rng *rand.Rand) (text string, positions map[string][]int) {
+	background := n
+	for _, m := range motifs {
+		background -= len(m)
+	}
+	if background+1 < len(motifs) {
+		panic("motifs do not fit in the string")
+	}
+
+	// Pick distinct points in the background to plant the motifs at,
+	// in a random order.
+	points := rng.Perm(background + 1)[:len(motifs)]
+	sort.Ints(points)
+	order := rng.Perm(len(motifs))
+
+	bytes := make([]byte, 0, n)
+	positions = map[string][]int{}
+	prev := 0
+	for i, point := range points {
+		bytes = append(bytes, randomStringN(point-prev, alpha, rng)...)
+		m := motifs[order[i]]
+		positions[m] = append(positions[m], len(bytes))
+		bytes = append(bytes, m...)
+		prev = point
+	}
+	bytes = append(bytes, randomStringN(background-prev, alpha, rng)...)
+
+	return string(bytes), positions
+}
@@ -0,0 +1,161 @@
+package bwt
+
+// lcpInterval is an open interval on the stack in a bottom-up traversal
+// of the LCP intervals. It records the interval's lcp value and the number
+// of l-indices seen so far, i.e., the positions inside the interval where
+// the lcp array equals its lcp value.
+type lcpInterval struct {
+	lcp      int32
+	lindices int
+}
+
+// RightExtensions returns, for each internal LCP interval of x, the number
+// of distinct characters that follow the interval's common prefix, i.e.,
+// the number of children the interval has in the suffix tree. The sentinel
+// counts as a character, so every internal interval has at least two
+// extensions, and every interval corresponds to a right-maximal repeat
+// (the root to the empty string).
+//
+// The suffix array sa must include the sentinel, and lcp[i] must be the
+// length of the longest common prefix of the suffixes at sa[i-1] and sa[i],
+// with lcp[0] == 0. The intervals are reported in the order a bottom-up
+// traversal closes them, so nested intervals come before the intervals
+// that contain them and the root interval is last.
+func RightExtensions(x string, sa []int32, lcp []int32) []int {
+	branching := []int{}
+	if len(sa) < 2 {
+		return branching // no internal intervals
+	}
+
+	stack := []lcpInterval{{lcp: 0}}
+	for k := 1; k <= len(sa); k++ {
+		h := int32(-1) // closes all intervals after the last suffix
+		if k < len(sa) {
+			h = lcp[k]
+		}
+
+		for len(stack) > 0 && h < stack[len(stack)-1].lcp {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			branching = append(branching, top.lindices+1)
+		}
+
+		switch {
+		case h < 0:
+			// done
+		case h == stack[len(stack)-1].lcp:
+			stack[len(stack)-1].lindices++
+		default:
+			stack = append(stack, lcpInterval{lcp: h, lindices: 1})
+		}
+	}
+
+	return branching
+}
+
+// Lcp computes the lcp array for x and its suffix array sa, including the
+// sentinel, as from PrefixDoubling: lcp[i] is the length of the longest
+// common prefix of the suffixes in rows i-1 and i, and lcp[0] is 0. The
+// result has len(sa) entries and takes linear time with Kasai's
+// algorithm, which walks the suffixes in text order through the inverse
+// suffix array, using that the lcp of the suffix at i+1 with its
+// predecessor is at most one smaller than the lcp of the suffix at i.
+func Lcp(x string, sa []int32) []int32 {
+	n := len(sa)
+	rank := make([]int32, n)
+	for i, j := range sa {
+		rank[j] = int32(i)
+	}
+	lcp := make([]int32, n)
+	h := 0
+	for i := 0; i < len(x); i++ {
+		r := rank[i]
+		if r == 0 {
+			h = 0
+			continue
+		}
+		j := int(sa[r-1])
+		for i+h < len(x) && j+h < len(x) && x[i+h] == x[j+h] {
+			h++
+		}
+		lcp[r] = int32(h)
+		if h > 0 {
+			h--
+		}
+	}
+	return lcp
+}
+
+// RepeatContent returns the fraction of x covered by repeats of length at
+// least minLen, i.e., the fraction of positions that lie inside an
+// occurrence of a string of at least minLen characters that occurs at
+// least twice in x. The longest repeated prefix of each suffix is the
+// larger lcp value with its neighbours in the suffix array, and the union
+// of those prefixes that are long enough is the covered part, so it runs
+// in O(n log n) time, dominated by building the suffix array.
+func RepeatContent(x string, minLen int) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	if minLen < 1 {
+		minLen = 1
+	}
+	sa := PrefixDoubling(x)
+	lcp := Lcp(x, sa)
+
+	// Sweep the text, extending the covered region with each suffix's
+	// longest repeated prefix.
+	longest := make([]int, len(x))
+	for r := 1; r < len(sa); r++ {
+		if h := int(lcp[r]); h >= minLen {
+			if h > longest[sa[r]] {
+				longest[sa[r]] = h
+			}
+			if h > longest[sa[r-1]] {
+				longest[sa[r-1]] = h
+			}
+		}
+	}
+	covered, end := 0, 0
+	for i, h := range longest {
+		if i+h > end {
+			end = i + h
+		}
+		if i < end {
+			covered++
+		}
+	}
+	return float64(covered) / float64(len(x))
+}
+
+// LongestBorder returns the length of the longest proper border of x, the
+// longest string other than x that is both a prefix and a suffix of x,
+// i.e., the last value of the KMP failure function. A suffix is a prefix
+// of x exactly when its lcp with x is its whole length, and then it sorts
+// before x, so the borders are found by scanning the suffix array back
+// from x's row while keeping the running minimum of the lcp array, which
+// is the lcp of each suffix with x. It runs in O(n log n) time, dominated
+// by building the suffix array.
+func LongestBorder(x string) int {
+	n := len(x)
+	if n < 2 {
+		return 0
+	}
+	sa := PrefixDoubling(x)
+	lcp := Lcp(x, sa)
+	r0 := 0
+	for sa[r0] != 0 {
+		r0++
+	}
+	border := 0
+	h := int32(n)
+	for r := r0 - 1; r > 0 && h > 0; r-- {
+		if lcp[r+1] < h {
+			h = lcp[r+1]
+		}
+		if length := int32(n) - sa[r]; length <= h && int(length) > border {
+			border = int(length)
+		}
+	}
+	return border
+}
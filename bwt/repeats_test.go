@@ -0,0 +1,166 @@
+package bwt
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// naiveLcp computes the lcp array for x and sa by comparing neighbouring
+// suffixes directly.
+func naiveLcp(x string, sa []int32) []int32 {
+	lcp := make([]int32, len(sa))
+	for i := 1; i < len(sa); i++ {
+		a, b := x[sa[i-1]:], x[sa[i]:]
+		for int(lcp[i]) < len(a) && int(lcp[i]) < len(b) && a[lcp[i]] == b[lcp[i]] {
+			lcp[i]++
+		}
+	}
+	return lcp
+}
+
+// naiveBranching finds all right-maximal repeats in x by enumerating its
+// substrings and returns the number of distinct right extensions of each.
+func naiveBranching(x string) []int {
+	y := x + "\x00" // the sentinel is a valid extension
+	branching := []int{}
+	seen := map[string]bool{}
+	for i := 0; i <= len(x); i++ {
+		for j := i; j <= len(x); j++ {
+			w := x[i:j]
+			if seen[w] {
+				continue
+			}
+			seen[w] = true
+
+			ext := map[byte]bool{}
+			for k := 0; k+len(w) < len(y); k++ {
+				if strings.HasPrefix(y[k:], w) {
+					ext[y[k+len(w)]] = true
+				}
+			}
+			if len(ext) > 1 {
+				branching = append(branching, len(ext))
+			}
+		}
+	}
+	return branching
+}
+
+func TestRightExtensions(t *testing.T) {
+	check := func(x string) {
+		sa := PrefixDoubling(x)
+		branching := RightExtensions(x, sa, naiveLcp(x, sa))
+		expected := naiveBranching(x)
+		sort.Ints(branching)
+		sort.Ints(expected)
+		if len(branching) != len(expected) {
+			t.Fatalf("Expected %d right-maximal repeats in %q, got %d",
+				len(expected), x, len(branching))
+		}
+		for i := range branching {
+			if branching[i] != expected[i] {
+				t.Fatalf("Branching in %q: got %v, expected %v", x, branching, expected)
+			}
+		}
+	}
+
+	for _, x := range []string{"", "a", "aaaa", "mississippi", "abcabcabc"} {
+		check(x)
+	}
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		check(randomStringN(rng.Intn(20), "acgt", rng))
+	}
+}
+
+func TestKasaiLcp(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := randomStringN(rng.Intn(50), "ab", rng)
+		sa := PrefixDoubling(x)
+		lcp, expected := Lcp(x, sa), naiveLcp(x, sa)
+		if len(lcp) != len(sa) || lcp[0] != 0 {
+			t.Fatalf("Expected %d lcp values starting with 0 for %q, got %v", len(sa), x, lcp)
+		}
+		for j := range expected {
+			if lcp[j] != expected[j] {
+				t.Fatalf("Expected lcp %v for %q, got %v", expected, x, lcp)
+			}
+		}
+	}
+}
+
+func TestRepeatContent(t *testing.T) {
+	naive := func(x string, minLen int) float64 {
+		if len(x) == 0 {
+			return 0
+		}
+		covered := make([]bool, len(x))
+		for i := range x {
+			for j := i + 1; j < len(x); j++ {
+				for l := minLen; j+l <= len(x); l++ {
+					if x[i:i+l] != x[j:j+l] {
+						break
+					}
+					for k := 0; k < l; k++ {
+						covered[i+k], covered[j+k] = true, true
+					}
+				}
+			}
+		}
+		count := 0
+		for _, c := range covered {
+			if c {
+				count++
+			}
+		}
+		return float64(count) / float64(len(x))
+	}
+
+	rng := newRandomSeed(t)
+	inputs := []string{"", "a", "aa", "abab", "abcd", "mississippi"}
+	for i := 0; i < 30; i++ {
+		inputs = append(inputs, randomStringN(rng.Intn(40), "acgt", rng))
+	}
+	for _, x := range inputs {
+		for _, minLen := range []int{1, 2, 3, 5} {
+			if content, expected := RepeatContent(x, minLen), naive(x, minLen); content != expected {
+				t.Errorf("RepeatContent(%q, %d) = %f, expected %f", x, minLen, content, expected)
+			}
+		}
+	}
+}
+
+func TestLongestBorder(t *testing.T) {
+	kmp := func(x string) int {
+		if len(x) == 0 {
+			return 0
+		}
+		fail := make([]int, len(x))
+		for i := 1; i < len(x); i++ {
+			k := fail[i-1]
+			for k > 0 && x[i] != x[k] {
+				k = fail[k-1]
+			}
+			if x[i] == x[k] {
+				k++
+			}
+			fail[i] = k
+		}
+		return fail[len(x)-1]
+	}
+
+	for x, expected := range map[string]int{"": 0, "a": 0, "aa": 1, "abab": 2, "abcab": 2, "aaaa": 3, "abc": 0, "abacaba": 3} {
+		if border := LongestBorder(x); border != expected {
+			t.Errorf("Expected the longest border of %q to be %d, got %d", x, expected, border)
+		}
+	}
+	rng := newRandomSeed(t)
+	for i := 0; i < 100; i++ {
+		x := randomStringN(rng.Intn(30), "ab", rng)
+		if border, expected := LongestBorder(x), kmp(x); border != expected {
+			t.Errorf("Expected the longest border of %q to be %d, got %d", x, expected, border)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package bwt
+
+import (
+	"sort"
+	"strings"
+)
+
+// RIndex is an r-index: an FM-index whose size depends on the number r of
+// runs of equal characters in the BWT, rather than the length of the text,
+// so it suits highly repetitive texts, such as collections of similar
+// genomes, where r is much smaller than n.
+//
+// The BWT is stored as its runs, and the rank of a character is found by
+// binary search among the runs of that character. For locating, the
+// suffix array is sampled at the first and last row of every run, 2r
+// entries in all. Backward search keeps the suffix array entry of the
+// last row of the interval as it goes (the toehold): if the character in
+// that row is the next one in the pattern, the entry just decreases by
+// one, and otherwise the last occurrence of the character in the interval
+// ends a run, so its entry is sampled. From the toehold, the rest of the
+// interval is filled in upwards with phi, phi(SA[i]) = SA[i-1], for which
+// the entries at the first rows of the runs are enough: within a run,
+// phi(p) = phi(q) + p - q, where q is the closest sampled position at or
+// before p. Locating occ occurrences of p thus takes O(|p| log r + occ
+// log r) time, and the index takes O(r) space.
+//
+// For matching statistics, the index also stores the thresholds of
+// Bannai, Gagie and I: between two consecutive runs of a symbol a, the
+// threshold is the first row with the smallest lcp value from the last a
+// before it to the first a after it. A row between the runs shares at
+// least as long a prefix with the last a above it as with the first a
+// below it if it is above the threshold, and otherwise the other way
+// round, so a match that cannot be extended with a in that row continues
+// longest from the nearer side of the threshold. That takes another r
+// entries.
+type RIndex struct {
+	alpha  *Alphabet
+	n      int     // the length of the BWT, the text plus the sentinel
+	ctab   []int   // ctab[a] is the number of symbols smaller than a
+	starts []int32 // starts[j] is the first row of run j
+	heads  []byte  // heads[j] is the symbol of run j
+	ends   []int32 // ends[j] is the suffix array entry at the last row of run j
+
+	// thresholds[j] is the threshold between run j and the previous run
+	// of its symbol, or 0 if it is the symbol's first run.
+	thresholds []int32
+
+	// runsOf[a] lists the runs of symbol a, and ranksOf[a][k] is the
+	// number of a's before the run runsOf[a][k].
+	runsOf, ranksOf [][]int32
+
+	// phiKeys holds, sorted, the suffix array entries at the first rows
+	// of the runs other than row 0, and phiValues[k] is the entry in the
+	// row above the one holding phiKeys[k].
+	phiKeys, phiValues []int32
+}
+
+// NewRIndex builds the r-index for x. The construction goes through the
+// full suffix array, which is dropped once the samples are taken; to
+// avoid it, build from the runs of the text with NewRIndexFromRLE.
+func NewRIndex(x string) *RIndex {
+	sa, bwt, alpha := mappedBwt(x)
+	return newRIndex(bwt, sa, Lcp(x, sa), alpha)
+}
+
+func newRIndex(bwt []byte, sa, lcp []int32, alpha *Alphabet) *RIndex {
+	heads, lens := []byte{}, []int{}
+	for i, a := range bwt {
+		if i > 0 && a == bwt[i-1] {
+			lens[len(lens)-1]++
+		} else {
+			heads, lens = append(heads, a), append(lens, 1)
+		}
+	}
+	r := rindexFromRuns(alpha, heads, lens)
+	firsts := make([]int32, len(r.starts))
+	for j, start := range r.starts {
+		firsts[j], r.ends[j] = sa[start], sa[r.end(j)-1]
+	}
+	r.setPhi(firsts)
+	r.setThresholds(lcp)
+	return r
+}
+
+// NewRIndexFromRLE builds the r-index for the text whose runs are runs,
+// without expanding them or building the suffix array. The runs of the
+// BWT are built as for BuildIndexFromRLE, and the samples are taken by
+// walking LF from the sentinel through all n suffixes with the index's
+// own rank, so the construction takes O(n log r) time. The samples need
+// only O(r) space for the r runs of the BWT, but the thresholds come from
+// the lcp array, which is computed from the text and phi and then takes
+// n bytes for the text and two int32s per character while it is mapped
+// to the rows by a second walk.
+func NewRIndexFromRLE(runs []Run) *RIndex {
+	r := rindexFromRuns(rleBwt(runs))
+	firsts := make([]int32, len(r.starts))
+	for i, pos := 0, int32(r.n-1); ; pos-- {
+		j := r.runOf(i)
+		if i == int(r.starts[j]) {
+			firsts[j] = pos
+		}
+		if i == r.end(j)-1 {
+			r.ends[j] = pos
+		}
+		if pos == 0 {
+			break
+		}
+		i = r.lf(i)
+	}
+	r.setPhi(firsts)
+
+	var b strings.Builder
+	for _, run := range runs {
+		if run.Len > 0 {
+			b.WriteString(strings.Repeat(string(run.Char), run.Len))
+		}
+	}
+	r.setThresholds(r.lcpFromPhi(b.String()))
+	return r
+}
+
+// lcpFromPhi computes the lcp array of the text x the index is for, as
+// Lcp does, but with phi in place of the suffix array: Kasai's algorithm
+// compares each suffix with the one in the row above, phi of its
+// position, and an LF walk from the sentinel's row puts the values in
+// row order.
+func (r *RIndex) lcpFromPhi(x string) []int32 {
+	plcp := make([]int32, len(x))
+	h := 0
+	for p := 0; p < len(x); p++ {
+		q := int(r.phi(int32(p)))
+		for p+h < len(x) && q+h < len(x) && x[p+h] == x[q+h] {
+			h++
+		}
+		plcp[p] = int32(h)
+		if h > 0 {
+			h--
+		}
+	}
+	lcp := make([]int32, r.n)
+	for i, pos := r.lf(0), len(x)-1; pos >= 0; i, pos = r.lf(i), pos-1 {
+		lcp[i] = plcp[pos]
+	}
+	return lcp
+}
+
+// lf returns the row of the suffix one position before the suffix in row
+// i.
+func (r *RIndex) lf(i int) int {
+	a := r.heads[r.runOf(i)]
+	rank, _ := r.rank(a, i)
+	return r.ctab[a] + rank
+}
+
+// rindexFromRuns builds the rank structures of the r-index for the BWT
+// whose runs are heads and lens, leaving the samples to be filled in.
+func rindexFromRuns(alpha *Alphabet, heads []byte, lens []int) *RIndex {
+	asize := alpha.Size()
+	r := &RIndex{
+		alpha:   alpha,
+		ctab:    make([]int, asize),
+		starts:  make([]int32, len(heads)),
+		heads:   heads,
+		ends:    make([]int32, len(heads)),
+		runsOf:  make([][]int32, asize),
+		ranksOf: make([][]int32, asize),
+	}
+	counts := make([]int, asize)
+	for j, a := range heads {
+		r.runsOf[a] = append(r.runsOf[a], int32(j))
+		r.ranksOf[a] = append(r.ranksOf[a], int32(counts[a]))
+		r.starts[j] = int32(r.n)
+		counts[a] += lens[j]
+		r.n += lens[j]
+	}
+	for a := 1; a < asize; a++ {
+		r.ctab[a] = r.ctab[a-1] + counts[a-1]
+	}
+	return r
+}
+
+// setPhi builds phi from the suffix array entries firsts[j] at the first
+// row of each run j and the entries at the last rows in ends.
+func (r *RIndex) setPhi(firsts []int32) {
+	type phiPair struct{ key, value int32 }
+	phi := make([]phiPair, 0, len(firsts)-1)
+	for j := 1; j < len(firsts); j++ {
+		phi = append(phi, phiPair{firsts[j], r.ends[j-1]})
+	}
+	sort.Slice(phi, func(i, j int) bool { return phi[i].key < phi[j].key })
+	r.phiKeys = make([]int32, len(phi))
+	r.phiValues = make([]int32, len(phi))
+	for k, pair := range phi {
+		r.phiKeys[k], r.phiValues[k] = pair.key, pair.value
+	}
+}
+
+// setThresholds computes the thresholds from the lcp array in row order.
+// A stack of the rows whose lcp value is smaller than that of every later
+// row seen so far holds, in increasing order, the first minimum of every
+// range that ends at the current row, so the threshold before each run is
+// found by binary search in it for the first row after the symbol's
+// previous run.
+func (r *RIndex) setThresholds(lcp []int32) {
+	r.thresholds = make([]int32, len(r.starts))
+	stack := []int32{}               // rows, with increasing lcp values
+	last := make([]int, len(r.ctab)) // last[a] is the row after a's previous run
+	row := 0
+	for j, start := range r.starts {
+		for ; row <= int(start); row++ {
+			for len(stack) > 0 && lcp[stack[len(stack)-1]] > lcp[row] {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, int32(row))
+		}
+		a := r.heads[j]
+		if last[a] > 0 {
+			k := sort.Search(len(stack), func(k int) bool { return int(stack[k]) >= last[a] })
+			r.thresholds[j] = stack[k]
+		}
+		last[a] = r.end(j)
+	}
+}
+
+// runOf returns the run that holds row i.
+func (r *RIndex) runOf(i int) int {
+	return sort.Search(len(r.starts), func(j int) bool { return int(r.starts[j]) > i }) - 1
+}
+
+// Len returns the length of the text.
+func (r *RIndex) Len() int {
+	return r.n - 1
+}
+
+// Runs returns the number of runs in the BWT, including the sentinel's.
+func (r *RIndex) Runs() int {
+	return len(r.starts)
+}
+
+// Count returns the number of occurrences of p in the text.
+func (r *RIndex) Count(p string) int {
+	L, R, _ := rSearch(r, p)
+	return R - L
+}
+
+// CountBytes is Count for a pattern given as a byte slice.
+func (r *RIndex) CountBytes(p []byte) int {
+	L, R, _ := rSearch(r, p)
+	return R - L
+}
+
+// Locate returns the positions where p occurs in the text, in suffix-array
+// order.
+func (r *RIndex) Locate(p string) []int32 {
+	return rLocate(r, p)
+}
+
+// LocateBytes is Locate for a pattern given as a byte slice.
+func (r *RIndex) LocateBytes(p []byte) []int32 {
+	return rLocate(r, p)
+}
+
+func rLocate[P pattern](r *RIndex, p P) []int32 {
+	L, R, toehold := rSearch(r, p)
+	if L >= R {
+		return []int32{}
+	}
+	positions := make([]int32, R-L)
+	positions[R-L-1] = toehold
+	for i := R - L - 1; i > 0; i-- {
+		positions[i-1] = r.phi(positions[i])
+	}
+	return positions
+}
+
+// rank returns the number of occurrences of symbol a in bwt[:i], and the
+// number of a's runs that start before i.
+func (r *RIndex) rank(a byte, i int) (rank, runs int) {
+	runsOf := r.runsOf[a]
+	k := sort.Search(len(runsOf), func(k int) bool { return int(r.starts[runsOf[k]]) >= i })
+	if k == 0 {
+		return 0, 0
+	}
+	j := int(runsOf[k-1])
+	end := r.end(j)
+	if end > i {
+		end = i
+	}
+	return int(r.ranksOf[a][k-1]) + end - int(r.starts[j]), k
+}
+
+// end returns the row after the last row of run j.
+func (r *RIndex) end(j int) int {
+	if j+1 < len(r.starts) {
+		return int(r.starts[j+1])
+	}
+	return r.n
+}
+
+// rSearch finds the interval [L, R) of p by backward search, along with
+// the suffix array entry of row R-1.
+func rSearch[P pattern](r *RIndex, p P) (L, R int, toehold int32) {
+	L, R = 0, r.n
+	toehold = r.ends[len(r.ends)-1]
+	if len(p) >= r.n {
+		return 0, 0, 0
+	}
+	for i := len(p) - 1; i >= 0; i-- {
+		a, ok := r.alpha.Map(p[i])
+		if !ok {
+			return 0, 0, 0
+		}
+		lo, _ := r.rank(a, L)
+		hi, runs := r.rank(a, R)
+		if lo >= hi {
+			return 0, 0, 0
+		}
+		if r.heads[r.runOf(R-1)] == a {
+			toehold--
+		} else {
+			// The last a in the interval ends one of a's runs.
+			toehold = r.ends[r.runsOf[a][runs-1]] - 1
+		}
+		L, R = r.ctab[a]+lo, r.ctab[a]+hi
+	}
+	return L, R, toehold
+}
+
+// phi returns the suffix array entry in the row above the one holding pos.
+func (r *RIndex) phi(pos int32) int32 {
+	k := sort.Search(len(r.phiKeys), func(k int) bool { return r.phiKeys[k] > pos }) - 1
+	return r.phiValues[k] + pos - r.phiKeys[k]
+}
@@ -0,0 +1,117 @@
+package bwt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRIndex(t *testing.T) {
+	rng := newRandomSeed(t)
+	texts := []string{"", "a", "mississippi", strings.Repeat("abc", 50)}
+	for i := 0; i < 5; i++ {
+		// Copies of a block with a few mutations, like similar genomes.
+		block := randomStringN(1+rng.Intn(60), "acgt", rng)
+		x := []byte(strings.Repeat(block, 2+rng.Intn(10)))
+		for j := rng.Intn(4); j > 0; j-- {
+			x[rng.Intn(len(x))] = "acgt"[rng.Intn(4)]
+		}
+		texts = append(texts, string(x))
+	}
+
+	for _, x := range texts {
+		idx, r := BuildIndex(x), NewRIndex(x)
+		if r.Len() != len(x) {
+			t.Errorf("Expected length %d, got %d", len(x), r.Len())
+		}
+		runs := len(RunLengthEncode(string(idx.BWT())))
+		if r.Runs() != runs {
+			t.Errorf("Expected %d runs in the BWT of %q, got %d", runs, x, r.Runs())
+		}
+
+		patterns := []string{"", "x", x + "a"}
+		for j := 0; j < 20 && len(x) > 0; j++ {
+			from := rng.Intn(len(x))
+			patterns = append(patterns, x[from:from+rng.Intn(len(x)-from+1)], randomStringN(1+rng.Intn(4), "acgt", rng))
+		}
+		for _, p := range patterns {
+			if count, expected := r.Count(p), idx.Count(p); count != expected {
+				t.Errorf("Expected Count(%q) = %d in %q, got %d", p, expected, x, count)
+			}
+			expected := idx.Locate(p)
+			if len(expected) == 0 {
+				expected = []int32{}
+			}
+			if located := r.Locate(p); !reflect.DeepEqual(located, expected) {
+				t.Errorf("Expected Locate(%q) = %v in %q, got %v", p, expected, x, located)
+			}
+			if located := r.LocateBytes([]byte(p)); !reflect.DeepEqual(located, expected) {
+				t.Errorf("Expected LocateBytes(%q) = %v in %q, got %v", p, expected, x, located)
+			}
+		}
+	}
+}
+
+func TestRIndexThresholds(t *testing.T) {
+	rng := newRandomSeed(t)
+	texts := []string{"", "a", "mississippi", strings.Repeat("abc", 20)}
+	for i := 0; i < 10; i++ {
+		block := randomStringN(1+rng.Intn(20), "acgt", rng)
+		x := []byte(strings.Repeat(block, 2+rng.Intn(5)))
+		for j := rng.Intn(4); j > 0; j-- {
+			x[rng.Intn(len(x))] = "acgt"[rng.Intn(4)]
+		}
+		texts = append(texts, string(x))
+	}
+
+	commonPrefix := func(x string, p, q int32) int {
+		k := 0
+		for int(p)+k < len(x) && int(q)+k < len(x) && x[int(p)+k] == x[int(q)+k] {
+			k++
+		}
+		return k
+	}
+	for _, x := range texts {
+		r, sa := NewRIndex(x), PrefixDoubling(x)
+		for j := range r.starts {
+			prev := j - 1
+			for prev >= 0 && r.heads[prev] != r.heads[j] {
+				prev--
+			}
+			threshold := int(r.thresholds[j])
+			if prev < 0 {
+				if threshold != 0 {
+					t.Errorf("Expected no threshold before the first run of a symbol in %q, got %d", x, threshold)
+				}
+				continue
+			}
+			above, below := r.end(prev)-1, int(r.starts[j])
+			if threshold <= above || threshold > below {
+				t.Errorf("Expected the threshold of run %d in %q in (%d, %d], got %d", j, x, above, below, threshold)
+				continue
+			}
+			// Above the threshold, a row shares at least as much with the
+			// run above, and from the threshold, with the run below.
+			for i := above + 1; i < below; i++ {
+				up, down := commonPrefix(x, sa[i], sa[above]), commonPrefix(x, sa[i], sa[below])
+				if (i < threshold && up < down) || (i >= threshold && down < up) {
+					t.Errorf("Expected row %d in %q to share the most with the run on its side of the threshold %d", i, x, threshold)
+				}
+			}
+		}
+	}
+}
+
+func TestNewRIndexFromRLE(t *testing.T) {
+	rng := newRandomSeed(t)
+	texts := []string{"", "a", "aaaa", "mississippi", strings.Repeat("abc", 50)}
+	for i := 0; i < 5; i++ {
+		block := randomStringN(1+rng.Intn(60), "acgt", rng)
+		texts = append(texts, strings.Repeat(block+strings.Repeat("a", rng.Intn(5)), 2+rng.Intn(10)))
+	}
+	for _, x := range texts {
+		if r, expected := NewRIndexFromRLE(RunLengthEncode(x)), NewRIndex(x); !reflect.DeepEqual(r, expected) {
+			t.Errorf("Expected the r-index of %q from its runs to equal NewRIndex's", x)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package bwt
+
+// Run is a run of Len copies of Char in a run-length encoded string.
+type Run struct {
+	Char byte
+	Len  int
+}
+
+// RunLengthEncode returns the runs of equal characters in x.
+func RunLengthEncode(x string) []Run {
+	runs := []Run{}
+	for i := 0; i < len(x); i++ {
+		if len(runs) > 0 && runs[len(runs)-1].Char == x[i] {
+			runs[len(runs)-1].Len++
+		} else {
+			runs = append(runs, Run{x[i], 1})
+		}
+	}
+	return runs
+}
+
+// rleOTabBlock is the distance between the rank checkpoints of the O-table
+// in an index built by BuildIndexFromRLE.
+const rleOTabBlock = 64
+
+// BuildIndexFromRLE builds the FM-index for the text whose runs are runs,
+// without expanding them. The alphabet is read from the runs, and the
+// index is compact, as from BuildIndexCompact: it keeps the BWT, the
+// tables and samples of the suffix array, not the text or its full suffix
+// array.
+//
+// The BWT is built by prepending the text to a run-length encoded BWT one
+// character at a time, from the last run to the first, so the
+// construction holds neither the text nor its suffix array, only the runs
+// of the BWT so far. The index itself holds the BWT a byte per character,
+// since an Index searches it directly, with a SparseOTab over it instead
+// of an OTab, and the suffix array samples are taken by walking LF from
+// the sentinel. For an index whose size depends only on the number of
+// runs, use NewRIndexFromRLE.
+func BuildIndexFromRLE(runs []Run) *Index {
+	alpha, heads, lens := rleBwt(runs)
+	n := 0
+	for _, l := range lens {
+		n += l
+	}
+	bwt := make([]byte, 0, n)
+	for j, a := range heads {
+		for k := 0; k < lens[j]; k++ {
+			bwt = append(bwt, a)
+		}
+	}
+	idx := &Index{
+		bwt:   bwt,
+		alpha: alpha,
+		ctab:  NewCTab(bwt, alpha.Size()),
+		otab:  NewSparseOTab(bwt, alpha.Size(), rleOTabBlock),
+	}
+	idx.samples = newSASamplesFromLF(len(bwt), compactSampleRate, idx.lf)
+	return idx
+}
+
+// rleBwt returns the alphabet of the text whose runs are runs and the runs
+// of its BWT, mapped to the alphabet, as their symbols and lengths.
+func rleBwt(runs []Run) (alpha *Alphabet, heads []byte, lens []int) {
+	var occurs [256]bool
+	for _, run := range runs {
+		if run.Len > 0 {
+			occurs[run.Char] = true
+		}
+	}
+	alpha = alphabetFromOccurrences(&occurs)
+	b := newRunBwt(alpha.Size())
+	for k := len(runs) - 1; k >= 0; k-- {
+		if runs[k].Len > 0 {
+			b.pushRun(alpha.symbols[runs[k].Char], runs[k].Len)
+		}
+	}
+	heads, lens = b.runs()
+	return alpha, heads, lens
+}
+
+// runBwtBlock is the target number of runs in a runBwt block. Blocks are
+// split when they grow to twice this size.
+const runBwtBlock = 64
+
+// runBwt is a run-length encoded BWT that grows at the front of its text,
+// like OnlineBWT, but stores the runs of the BWT instead of its symbols.
+// The runs are split into blocks of at most 2*64 runs, with a Fenwick tree
+// over the lengths of the blocks to find the block of a row, and, for
+// each symbol, a Fenwick tree counting its occurrences per block. Each
+// prepended character takes O(log r + 64) time for r runs, apart from
+// block splits, which rebuild the trees in O(asize*r/64) time.
+type runBwt struct {
+	asize    int
+	heads    [][]byte // heads[b][k] is the symbol of run k in block b
+	lens     [][]int  // lens[b][k] is the length of run k in block b
+	lengths  fenwick  // the lengths of the blocks
+	trees    []fenwick
+	counts   []int // counts[a] is the number of a's in the text
+	sentinel int   // the row of the whole text, where the BWT has the sentinel
+}
+
+// newRunBwt returns the transform of the empty text over an alphabet of
+// size asize, the lone sentinel.
+func newRunBwt(asize int) *runBwt {
+	b := &runBwt{
+		asize:  asize,
+		heads:  [][]byte{{0}},
+		lens:   [][]int{{1}},
+		counts: make([]int, asize),
+	}
+	b.rebuild()
+	return b
+}
+
+// rebuild recomputes the Fenwick trees from the blocks.
+func (b *runBwt) rebuild() {
+	lengths := make([]int, len(b.heads))
+	counts := make([][]int, b.asize)
+	for a := range counts {
+		counts[a] = make([]int, len(b.heads))
+	}
+	for blk, heads := range b.heads {
+		for k, a := range heads {
+			lengths[blk] += b.lens[blk][k]
+			counts[a][blk] += b.lens[blk][k]
+		}
+	}
+	b.lengths = newFenwick(lengths)
+	b.trees = make([]fenwick, b.asize)
+	for a := range counts {
+		b.trees[a] = newFenwick(counts[a])
+	}
+}
+
+// find returns the block that holds row i and the offset of i in it. Row
+// n maps to the end of the last block.
+func (b *runBwt) find(i int) (blk, offset int) {
+	blk, offset = b.lengths.find(i)
+	if blk == len(b.heads) {
+		blk--
+		offset = b.lengths.sum(len(b.heads)) - b.lengths.sum(blk)
+	}
+	return blk, offset
+}
+
+// rank returns the number of occurrences of a before row i.
+func (b *runBwt) rank(a byte, i int) int {
+	blk, offset := b.find(i)
+	rank := b.trees[a].sum(blk)
+	for k, l := range b.lens[blk] {
+		if offset <= 0 {
+			break
+		}
+		if l > offset {
+			l = offset
+		}
+		if b.heads[blk][k] == a {
+			rank += l
+		}
+		offset -= l
+	}
+	return rank
+}
+
+// pushRun prepends count copies of the symbol a to the text.
+func (b *runBwt) pushRun(a byte, count int) {
+	smaller := 0
+	for c := 1; c < int(a); c++ {
+		smaller += b.counts[c]
+	}
+	for ; count > 0; count-- {
+		// As in OnlineBWT.Push, the new suffix follows the sentinel
+		// suffix, the suffixes that start with a smaller symbol, and the
+		// suffixes a+y with y smaller than the old text.
+		row := 1 + smaller + b.rank(a, b.sentinel)
+		b.replaceSentinel(a)
+		b.insertSentinel(row)
+		b.counts[a]++
+		b.sentinel = row
+	}
+}
+
+// replaceSentinel replaces the sentinel's run by a run of a, merging it
+// with its neighbours in the block if they are runs of a too.
+func (b *runBwt) replaceSentinel(a byte) {
+	blk, offset := b.find(b.sentinel)
+	heads, lens := b.heads[blk], b.lens[blk]
+	k := 0
+	for offset > 0 {
+		offset -= lens[k]
+		k++
+	}
+	heads[k] = a
+	if k+1 < len(heads) && heads[k+1] == a {
+		lens[k] += lens[k+1]
+		heads, lens = append(heads[:k+1], heads[k+2:]...), append(lens[:k+1], lens[k+2:]...)
+	}
+	if k > 0 && heads[k-1] == a {
+		lens[k-1] += lens[k]
+		heads, lens = append(heads[:k], heads[k+1:]...), append(lens[:k], lens[k+1:]...)
+	}
+	b.heads[blk], b.lens[blk] = heads, lens
+	b.trees[0].add(blk, -1)
+	b.trees[a].add(blk, 1)
+}
+
+// insertSentinel inserts a run of the sentinel at row i, splitting the run
+// that holds i if i is inside it.
+func (b *runBwt) insertSentinel(i int) {
+	blk, offset := b.find(i)
+	heads, lens := b.heads[blk], b.lens[blk]
+	k := 0
+	for k < len(lens) && offset >= lens[k] {
+		offset -= lens[k]
+		k++
+	}
+	if offset > 0 {
+		// Split run k at the offset, and insert between the halves.
+		heads = append(heads[:k+1], append([]byte{heads[k]}, heads[k+1:]...)...)
+		lens = append(lens[:k+1], append([]int{lens[k] - offset}, lens[k+1:]...)...)
+		lens[k] = offset
+		k++
+	}
+	heads = append(heads[:k], append([]byte{0}, heads[k:]...)...)
+	lens = append(lens[:k], append([]int{1}, lens[k:]...)...)
+	b.heads[blk], b.lens[blk] = heads, lens
+
+	if len(heads) < 2*runBwtBlock {
+		b.lengths.add(blk, 1)
+		b.trees[0].add(blk, 1)
+		return
+	}
+	half := len(heads) / 2
+	b.heads = append(b.heads, nil)
+	copy(b.heads[blk+2:], b.heads[blk+1:])
+	b.heads[blk], b.heads[blk+1] = heads[:half:half], append([]byte{}, heads[half:]...)
+	b.lens = append(b.lens, nil)
+	copy(b.lens[blk+2:], b.lens[blk+1:])
+	b.lens[blk], b.lens[blk+1] = lens[:half:half], append([]int{}, lens[half:]...)
+	b.rebuild()
+}
+
+// runs returns the runs of the BWT, merging the runs of equal symbols
+// that meet at block boundaries.
+func (b *runBwt) runs() (heads []byte, lens []int) {
+	for blk := range b.heads {
+		for k, a := range b.heads[blk] {
+			if len(heads) > 0 && heads[len(heads)-1] == a {
+				lens[len(lens)-1] += b.lens[blk][k]
+			} else {
+				heads = append(heads, a)
+				lens = append(lens, b.lens[blk][k])
+			}
+		}
+	}
+	return heads, lens
+}
@@ -0,0 +1,51 @@
+package bwt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunLengthEncode(t *testing.T) {
+	expected := []Run{{'a', 3}, {'b', 1}, {'a', 2}}
+	if runs := RunLengthEncode("aaabaa"); !reflect.DeepEqual(runs, expected) {
+		t.Errorf("Expected %v, got %v", expected, runs)
+	}
+	if runs := RunLengthEncode(""); len(runs) != 0 {
+		t.Errorf("Expected no runs, got %v", runs)
+	}
+}
+
+func TestBuildIndexFromRLE(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		// A repetitive text: copies of a unit with a few mutations.
+		unit := randomStringN(50, "acgt", rng)
+		var b strings.Builder
+		for c := 0; c < 20; c++ {
+			b.WriteString(unit)
+			b.WriteString(strings.Repeat("a", rng.Intn(5)))
+		}
+		x := b.String()
+
+		idx, expected := BuildIndexFromRLE(RunLengthEncode(x)), BuildIndex(x)
+		if !reflect.DeepEqual(idx.bwt, expected.bwt) {
+			t.Fatalf("Expected the BWT of the expanded text")
+		}
+		if idx.Len() != len(x) || idx.Extract(0, len(x)) != x {
+			t.Errorf("Expected to extract the expanded text")
+		}
+		for j := 0; j < 10; j++ {
+			p := randomStringN(1+rng.Intn(6), "acgt", rng)
+			if !reflect.DeepEqual(idx.Locate(p), expected.Locate(p)) {
+				t.Errorf("Expected the same occurrences of %q as BuildIndex", p)
+			}
+		}
+	}
+
+	// Empty runs are ignored.
+	idx := BuildIndexFromRLE([]Run{{'a', 2}, {'b', 0}, {'a', 1}})
+	if idx.Len() != 3 || idx.Count("aaa") != 1 || idx.Alphabet().Size() != 2 {
+		t.Errorf("Expected the index of aaa")
+	}
+}
@@ -0,0 +1,122 @@
+package bwt
+
+// leastRotation returns the start of the lexicographically smallest
+// rotation of x using Booth's algorithm, which runs the Knuth-Morris-Pratt
+// failure function over x+x while tracking the best start seen. When
+// several rotations are equal, the smallest start is returned.
+func leastRotation(x string) int {
+	n := len(x)
+	if n == 0 {
+		return 0
+	}
+
+	f := make([]int, 2*n)
+	for i := range f {
+		f[i] = -1
+	}
+	k := 0
+	for j := 1; j < 2*n; j++ {
+		a := x[j%n]
+		i := f[j-k-1]
+		for i != -1 && a != x[(k+i+1)%n] {
+			if a < x[(k+i+1)%n] {
+				k = j - i - 1
+			}
+			i = f[i]
+		}
+		if i == -1 && a != x[(k+i+1)%n] {
+			if a < x[(k+i+1)%n] {
+				k = j
+			}
+			f[j-k] = -1
+		} else {
+			f[j-k] = i + 1
+		}
+	}
+	return k % n
+}
+
+// MinimalRotation returns the lexicographically smallest rotation of x,
+// a canonical form for x as a circular string. It runs in O(n) time.
+func MinimalRotation(x string) string {
+	k := leastRotation(x)
+	return x[k:] + x[:k]
+}
+
+// CircularSuffixArray returns the suffix array of x as a circular string:
+// the starts of the rotations of x in sorted order, with equal rotations,
+// in a periodic x, ordered by their starts. That is the suffix array of
+// x+x restricted to the starts in x, but it is computed without doubling
+// x, by prefix doubling where the second key wraps around the end of x.
+// Each iteration sorts with two stable counting-sort passes, so it runs in
+// O(n log n) time. There is no sentinel, so the result has length len(x).
+func CircularSuffixArray(x string) []int32 {
+	n := len(x)
+	sa := make([]int32, n)
+	if n == 0 {
+		return sa
+	}
+	rank := make([]int32, n)
+	for i := range sa {
+		sa[i] = int32(i)
+		rank[i] = int32(x[i])
+	}
+	buf := make([]int32, n)
+	next := make([]int32, n)
+	buckets := 256
+	if n > buckets {
+		buckets = n
+	}
+	counts := make([]int, buckets)
+
+	for k := 1; ; k *= 2 {
+		// Stable counting sort by the second key, then by the first.
+		for _, offset := range [2]int{k % n, 0} {
+			for b := range counts {
+				counts[b] = 0
+			}
+			for _, i := range sa {
+				counts[rank[(int(i)+offset)%n]]++
+			}
+			acc := 0
+			for b, c := range counts {
+				counts[b] = acc
+				acc += c
+			}
+			for _, i := range sa {
+				b := rank[(int(i)+offset)%n]
+				buf[counts[b]] = i
+				counts[b]++
+			}
+			sa, buf = buf, sa
+		}
+
+		sigma := int32(0)
+		next[sa[0]] = 0
+		for j := 1; j < n; j++ {
+			prev, cur := int(sa[j-1]), int(sa[j])
+			if rank[prev] != rank[cur] || rank[(prev+k)%n] != rank[(cur+k)%n] {
+				sigma++
+			}
+			next[cur] = sigma
+		}
+		rank, next = next, rank
+		// The rotations are sorted when the ranks are unique, or, for a
+		// periodic x, when the compared prefixes cover whole rotations.
+		if int(sigma)+1 == n || 2*k >= n {
+			break
+		}
+	}
+	return sa
+}
+
+// SmallestRotationIndex returns the start of the lexicographically
+// smallest rotation of x, the first entry in the cyclic suffix array of x.
+// When several rotations are equal, for a periodic x, the smallest start
+// is returned. It returns 0 for the empty string.
+func SmallestRotationIndex(x string) int {
+	if len(x) == 0 {
+		return 0
+	}
+	return int(CircularSuffixArray(x)[0])
+}
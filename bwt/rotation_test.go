@@ -0,0 +1,75 @@
+package bwt
+
+import (
+	"testing"
+)
+
+// naiveMinimalRotation finds the smallest rotation of x, and the smallest
+// start of it, by comparing all rotations.
+func naiveMinimalRotation(x string) (string, int) {
+	best, start := x, 0
+	for i := 1; i < len(x); i++ {
+		if rot := x[i:] + x[:i]; rot < best {
+			best, start = rot, i
+		}
+	}
+	return best, start
+}
+
+func TestMinimalRotation(t *testing.T) {
+	rng := newRandomSeed(t)
+	inputs := []string{"", "a", "ba", "abab", "bbbaaa", "cabcab", "mississippi"}
+	for i := 0; i < 50; i++ {
+		inputs = append(inputs, randomStringN(rng.Intn(12), "ab", rng))
+	}
+	for _, x := range inputs {
+		expected, start := naiveMinimalRotation(x)
+		if rot := MinimalRotation(x); rot != expected {
+			t.Errorf("MinimalRotation(%q) = %q, expected %q", x, rot, expected)
+		}
+		if k := leastRotation(x); k != start {
+			t.Errorf("leastRotation(%q) = %d, expected %d", x, k, start)
+		}
+	}
+}
+
+func TestSmallestRotationIndex(t *testing.T) {
+	rng := newRandomSeed(t)
+	inputs := []string{"", "a", "aaaa", "ba", "abab", "bababa", "cabcab", "abcabcabc", "mississippi"}
+	for i := 0; i < 100; i++ {
+		inputs = append(inputs, randomStringN(rng.Intn(12), "ab", rng))
+	}
+	for _, x := range inputs {
+		if _, start := naiveMinimalRotation(x); SmallestRotationIndex(x) != start {
+			t.Errorf("SmallestRotationIndex(%q) = %d, expected %d", x, SmallestRotationIndex(x), start)
+		}
+	}
+}
+
+func TestCircularSuffixArray(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 50; i++ {
+		x := randomStringN(rng.Intn(20), "abc", rng)
+		if i%5 == 0 {
+			x = x + x + x // periodic, with equal rotations
+		}
+		sa := CircularSuffixArray(x)
+		if len(sa) != len(x) {
+			t.Fatalf("Expected %d rotations, got %d", len(x), len(sa))
+		}
+		seen := map[int32]bool{}
+		for _, i := range sa {
+			seen[i] = true
+		}
+		if len(seen) != len(x) {
+			t.Errorf("Expected the starts of all rotations of %q, got %v", x, sa)
+		}
+		for j := 1; j < len(sa); j++ {
+			prev, cur := int(sa[j-1]), int(sa[j])
+			a, b := x[prev:]+x[:prev], x[cur:]+x[:cur]
+			if a > b || (a == b && prev > cur) {
+				t.Errorf("Rotations %d and %d of %q are out of order", prev, cur, x)
+			}
+		}
+	}
+}
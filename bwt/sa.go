@@ -0,0 +1,305 @@
+package bwt
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// SAInt is the integer types a suffix array can be built with.
+type SAInt interface {
+	~int32 | ~int64
+}
+
+// calcRank0 maps the characters in x to their rank in the sorted alphabet
+// of x, reserving rank 0 for the sentinel that terminates the string. The
+// returned slice has length len(x)+1 with the sentinel at the end, and
+// sigma is the number of distinct ranks (including the sentinel).
+func calcRank0[T SAInt](x string) (rank []T, sigma T) {
+	var occurs [256]bool
+	for i := 0; i < len(x); i++ {
+		occurs[x[i]] = true
+	}
+
+	var alpha [256]T
+	sigma = 1 // the sentinel
+	for a := 0; a < 256; a++ {
+		if occurs[a] {
+			alpha[a] = sigma
+			sigma++
+		}
+	}
+
+	rank = make([]T, len(x)+1)
+	for i := 0; i < len(x); i++ {
+		rank[i] = alpha[x[i]]
+	}
+	return rank, sigma
+}
+
+// calcRankLast is calcRank0 for a sentinel that sorts after all other
+// characters: the characters get ranks [0, sigma-1) and the sentinel gets
+// rank sigma-1.
+func calcRankLast[T SAInt](x string) (rank []T, sigma T) {
+	rank, sigma = calcRank0[T](x)
+	for i := range rank[:len(x)] {
+		rank[i]--
+	}
+	rank[len(x)] = sigma - 1
+	return rank, sigma
+}
+
+// getRank returns rank[i], padding with 0 beyond the end of the string.
+//
+// The padding is only used for the second key of suffixes that have the
+// sentinel among their first k characters, and since the sentinel is
+// unique, those suffixes already have unique ranks, so the value of the
+// padding never decides their order. That makes 0 correct wherever the
+// sentinel sorts.
+func getRank[T SAInt](rank []T, i T) T {
+	if i < T(len(rank)) {
+		return rank[i]
+	}
+	return 0
+}
+
+// radixSortBucketsN sorts sa by the key pairs (rank[sa[i]], rank[sa[i]+k])
+// using a least-significant-digit radix sort, sorting each key in the
+// given number of passes over digits of the given number of bits. The
+// digits must cover the ranks, i.e., passes*bits must be at least the
+// number of bits in the largest rank, and bits must be between 1 and 16.
+// Prefix doubling uses one pass per byte of T; other configurations trade
+// passes for the size of the bucket table. The buffer buf must have the
+// same length as sa; it is used as scratch space and its content is
+// undefined afterwards.
+func radixSortBucketsN[T SAInt](rank, sa, buf []T, k T, passes, bits int) {
+	// Sort by the second key first, then stable sort by the first key.
+	mask := T(1)<<bits - 1
+	buckets := make([]int, 1<<bits)
+	src, dst := sa, buf
+	for _, offset := range [2]T{k, 0} {
+		for shift := 0; shift < passes*bits; shift += bits {
+			for b := range buckets {
+				buckets[b] = 0
+			}
+			for _, i := range src {
+				buckets[(getRank(rank, i+offset)>>shift)&mask]++
+			}
+			acc := 0
+			for b, count := range buckets {
+				buckets[b] = acc
+				acc += count
+			}
+			for _, i := range src {
+				b := (getRank(rank, i+offset) >> shift) & mask
+				dst[buckets[b]] = i
+				buckets[b]++
+			}
+			src, dst = dst, src
+		}
+	}
+	// An even number of passes leaves the result in sa.
+}
+
+// updateRanks computes the ranks of the suffixes in the sorted sa, where
+// suffixes get the same rank if they agree on the pair
+// (rank[i], rank[i+k]). The new ranks are written to out, and the number
+// of distinct ranks is returned.
+func updateRanks[T SAInt](rank, sa, out []T, k T) T {
+	sigma := T(0)
+	out[sa[0]] = 0
+	for i := 1; i < len(sa); i++ {
+		prev, cur := sa[i-1], sa[i]
+		if rank[prev] != rank[cur] || getRank(rank, prev+k) != getRank(rank, cur+k) {
+			sigma++
+		}
+		out[cur] = sigma
+	}
+	return sigma + 1
+}
+
+// PrefixDoubling computes the suffix array of x, including the sentinel,
+// using the prefix-doubling algorithm. The result has length len(x)+1, and
+// since the sentinel is the smallest character, sa[0] == len(x).
+//
+// Each iteration radix sorts the suffixes by their first 2k characters,
+// given the ranks of their first k characters, so the running time is
+// O(n log n). SAIS computes the same array in linear time.
+func PrefixDoubling(x string) []int32 {
+	return PrefixDoublingProgress(x, nil)
+}
+
+// PrefixDoublingProgress computes the suffix array like PrefixDoubling, but
+// calls progress after each doubling iteration with the iteration number,
+// counting from zero, the number of distinct ranks found so far, and the
+// number of suffixes. The construction is done when sigma reaches n, which
+// takes at most about log2(n) iterations. A nil progress is not called.
+func PrefixDoublingProgress(x string, progress func(iteration int, sigma, n int32)) []int32 {
+	return prefixDoubling[int32](x, SentinelFirst, progress)
+}
+
+// PrefixDoublingWidth computes the suffix array like PrefixDoubling, with
+// the integer type of the suffix array chosen by the caller, so
+// PrefixDoublingWidth[int64] can index texts too long for 32-bit
+// positions, and PrefixDoublingWidth[int32] is the same as PrefixDoubling.
+func PrefixDoublingWidth[T SAInt](x string) []T {
+	return prefixDoubling[T](x, SentinelFirst, nil)
+}
+
+// PrefixDoublingWorkingSet returns the number of bytes PrefixDoubling
+// allocates for a text of length n: the suffix array, the ranks and the
+// radix sort's buffer, each n+1 int32s.
+func PrefixDoublingWorkingSet(n int) int64 {
+	return 3 * int64(n+1) * int64(int32Size)
+}
+
+// PrefixDoublingE computes the suffix array like PrefixDoubling, but
+// returns an error instead of crashing when the working set does not fit.
+// If budget is positive, it is the most the construction may allocate,
+// and the working set is checked against it before anything is
+// allocated. An allocation the runtime rejects outright, such as one
+// longer than the address space allows, is also recovered into an error.
+// The Go runtime cannot recover from running out of memory, so a budget
+// below the memory actually available is the only reliable guard.
+func PrefixDoublingE(x string, budget int64) (sa []int32, err error) {
+	if need := PrefixDoublingWorkingSet(len(x)); budget > 0 && need > budget {
+		return nil, fmt.Errorf("suffix array of %d characters needs %d bytes, over the budget of %d", len(x), need, budget)
+	}
+	if len(x) >= math.MaxInt32 {
+		return nil, fmt.Errorf("text of %d characters is too long for 32-bit positions", len(x))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			sa, err = nil, fmt.Errorf("building the suffix array failed: %v", r)
+		}
+	}()
+	return PrefixDoubling(x), nil
+}
+
+// SentinelOrder determines where the sentinel sorts relative to the
+// characters of the string.
+type SentinelOrder int
+
+const (
+	// SentinelFirst sorts the sentinel before all characters, so the
+	// empty suffix is first in the suffix array. This is the default.
+	SentinelFirst SentinelOrder = iota
+	// SentinelLast sorts the sentinel after all characters, so the
+	// empty suffix is last in the suffix array.
+	SentinelLast
+)
+
+// PrefixDoublingOrder computes the suffix array like PrefixDoubling, with
+// the sentinel sorted according to order. With SentinelLast, sa[len(x)] is
+// len(x).
+func PrefixDoublingOrder(x string, order SentinelOrder) []int32 {
+	return prefixDoubling[int32](x, order, nil)
+}
+
+func prefixDoubling[T SAInt](x string, order SentinelOrder, progress func(iteration int, sigma, n T)) []T {
+	var zero T
+	return prefixDoublingN(x, order, progress, int(unsafe.Sizeof(zero)), 8)
+}
+
+// prefixDoublingN is prefixDoubling with the radix sort configured as in
+// radixSortBucketsN.
+func prefixDoublingN[T SAInt](x string, order SentinelOrder, progress func(iteration int, sigma, n T), passes, bits int) []T {
+	var rank []T
+	if order == SentinelLast {
+		rank, _ = calcRankLast[T](x)
+	} else {
+		rank, _ = calcRank0[T](x)
+	}
+	n := T(len(rank))
+
+	sa := make([]T, n)
+	for i := range sa {
+		sa[i] = T(i)
+	}
+	iteration := 0
+	// The ranks of a text are consistent, so the rounds cannot fail.
+	sa, _ = doublingRounds(sa, rank, 1, passes, bits, func(_ []T, _, sigma T) error {
+		if progress != nil {
+			progress(iteration, sigma, n)
+		}
+		iteration++
+		return nil
+	})
+	return sa
+}
+
+// doublingRounds runs the prefix-doubling iterations from the suffixes sa
+// sorted by their first k characters, with ranks rank, until all ranks
+// are distinct, radix sorting as in radixSortBucketsN. After each
+// iteration it calls after with the new ranks, the prefix length they are
+// for, and the number of distinct ranks, and an error from after stops
+// the rounds. Ranks that are still not distinct once the prefixes cover
+// the whole text cannot come from a text, so they are an error too.
+func doublingRounds[T SAInt](sa, rank []T, k T, passes, bits int, after func(rank []T, k, sigma T) error) ([]T, error) {
+	n := T(len(rank))
+	buf := make([]T, n)
+	for ; ; k *= 2 {
+		radixSortBucketsN(rank, sa, buf, k, passes, bits)
+		sigma := updateRanks(rank, sa, buf, k)
+		rank, buf = buf, rank
+		if err := after(rank, 2*k, sigma); err != nil {
+			return nil, err
+		}
+		if sigma == n {
+			return sa, nil
+		}
+		if k >= (n+1)/2 {
+			return nil, fmt.Errorf("%d of %d ranks are distinct after prefixes of length %d", sigma, n, 2*k)
+		}
+	}
+}
+
+// CertifySA checks that sa is the suffix array of x, including the
+// sentinel, and returns the inverse suffix array as a certificate: with
+// it, VerifySACertificate checks sa again in O(n) time without sorting
+// anything. The proof is nil if sa is not the suffix array of x.
+func CertifySA(x string, sa []int32) (ok bool, proof []int32) {
+	if len(sa) != len(x)+1 {
+		return false, nil
+	}
+	isa := make([]int32, len(sa))
+	for i := range isa {
+		isa[i] = -1
+	}
+	for row, pos := range sa {
+		if pos < 0 || int(pos) >= len(sa) || isa[pos] >= 0 {
+			return false, nil // not a permutation
+		}
+		isa[pos] = int32(row)
+	}
+	if !VerifySACertificate(x, sa, isa) {
+		return false, nil
+	}
+	return true, isa
+}
+
+// VerifySACertificate checks that sa is the suffix array of x, given its
+// claimed inverse isa, in O(n) time. It checks that isa inverts sa, so sa
+// is a permutation, and that each pair of neighbouring suffixes is in
+// order: the suffix at p comes before the one at q if x[p] < x[q], or if
+// x[p] == x[q] and the suffix at p+1 comes before the one at q+1, which
+// isa tells in constant time. Since the order of the shorter suffixes was
+// itself checked, the neighbours all in order means sa is sorted.
+func VerifySACertificate(x string, sa, isa []int32) bool {
+	n := len(x)
+	if len(sa) != n+1 || len(isa) != n+1 || sa[0] != int32(n) {
+		return false
+	}
+	for row, pos := range sa {
+		if pos < 0 || int(pos) > n || isa[pos] != int32(row) {
+			return false
+		}
+	}
+	for i := 2; i <= n; i++ {
+		p, q := sa[i-1], sa[i]
+		if x[p] > x[q] || (x[p] == x[q] && isa[p+1] > isa[q+1]) {
+			return false
+		}
+	}
+	return true
+}
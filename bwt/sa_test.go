@@ -0,0 +1,216 @@
+package bwt
+
+import (
+	"fmt"
+	"testing"
+)
+
+// checkSuffixArray checks that sa is the suffix array of x, including the
+// sentinel.
+func checkSuffixArray(t *testing.T, x string, sa []int32) {
+	t.Helper()
+
+	if len(sa) != len(x)+1 {
+		t.Fatalf("Expected suffix array of length %d, got %d", len(x)+1, len(sa))
+	}
+	if sa[0] != int32(len(x)) {
+		t.Errorf("Expected the sentinel suffix first, got %d", sa[0])
+	}
+
+	seen := make([]bool, len(sa))
+	for _, i := range sa {
+		if i < 0 || int(i) >= len(sa) || seen[i] {
+			t.Fatalf("Suffix array %v is not a permutation", sa)
+		}
+		seen[i] = true
+	}
+
+	for i := 1; i < len(sa); i++ {
+		if x[sa[i-1]:] >= x[sa[i]:] {
+			t.Errorf("Suffixes out of order at %d: %q >= %q",
+				i, x[sa[i-1]:], x[sa[i]:])
+		}
+	}
+}
+
+func TestPrefixDoubling(t *testing.T) {
+	for _, x := range []string{"", "a", "aaaa", "mississippi", "abcabcabc"} {
+		checkSuffixArray(t, x, PrefixDoubling(x))
+	}
+
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(rng.Intn(50), "acgt", rng)
+		checkSuffixArray(t, x, PrefixDoubling(x))
+	}
+}
+
+func TestPrefixDoublingProgress(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "aaaaaaaaaaaaaaaa", randomStringN(1000, "acgt", rng)} {
+		calls := 0
+		lastSigma := int32(0)
+		sa := PrefixDoublingProgress(x, func(iteration int, sigma, n int32) {
+			if iteration != calls {
+				t.Errorf("Expected iteration %d, got %d", calls, iteration)
+			}
+			if n != int32(len(x)+1) || sigma < lastSigma || sigma > n {
+				t.Errorf("Unexpected progress: sigma %d (previous %d), n %d", sigma, lastSigma, n)
+			}
+			calls++
+			lastSigma = sigma
+		})
+
+		// Each iteration doubles the sorted prefix length, and we
+		// need at most one more to see that all ranks are distinct.
+		bound := 1
+		for 1<<(bound-1) < len(x)+1 {
+			bound++
+		}
+		if calls < 1 || calls > bound {
+			t.Errorf("Expected between 1 and %d calls for length %d, got %d", bound, len(x), calls)
+		}
+		if lastSigma != int32(len(x)+1) {
+			t.Errorf("Expected the last call to report all %d ranks, got %d", len(x)+1, lastSigma)
+		}
+
+		expected := PrefixDoubling(x)
+		for i := range sa {
+			if sa[i] != expected[i] {
+				t.Fatalf("Progress changed the suffix array at %d", i)
+			}
+		}
+	}
+}
+
+func TestSentinelOrder(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(rng.Intn(50), "acgt", rng)
+
+		// With the sentinel last, the order is the order of the
+		// suffixes terminated by a character larger than the rest.
+		sa := PrefixDoublingOrder(x, SentinelLast)
+		if len(sa) != len(x)+1 || sa[len(x)] != int32(len(x)) {
+			t.Fatalf("Expected the sentinel suffix last in %v", sa)
+		}
+		y := x + "\xff"
+		for j := 1; j < len(sa); j++ {
+			if y[sa[j-1]:] >= y[sa[j]:] {
+				t.Errorf("Suffixes out of order at %d in %q", j, x)
+			}
+		}
+		checkSuffixArray(t, x, PrefixDoublingOrder(x, SentinelFirst))
+
+		for _, order := range []SentinelOrder{SentinelFirst, SentinelLast} {
+			if z := RbwtOrder(BwtOrder(x, order), order); z != x {
+				t.Errorf("Order %d: expected %q, got %q", order, x, z)
+			}
+		}
+	}
+}
+
+func TestPrefixDoublingWidth(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(rng.Intn(500), "acgt", rng)
+		sa32, sa64 := PrefixDoublingWidth[int32](x), PrefixDoublingWidth[int64](x)
+		checkSuffixArray(t, x, sa32)
+		if len(sa32) != len(sa64) {
+			t.Fatalf("Expected lengths %d and %d to agree", len(sa32), len(sa64))
+		}
+		for j := range sa32 {
+			if int64(sa32[j]) != sa64[j] {
+				t.Fatalf("The 32- and 64-bit suffix arrays differ at %d", j)
+			}
+		}
+	}
+}
+
+// radixConfigs are the radix sort configurations, as passes per key and
+// bits per pass, that cover 32-bit ranks.
+var radixConfigs = [][2]int{{4, 8}, {2, 16}, {8, 4}, {32, 1}, {3, 11}}
+
+func TestRadixSortBucketsN(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "mississippi", randomStringN(1000, "acgt", rng), randomStringN(1000, "ab", rng)} {
+		for _, config := range radixConfigs {
+			sa := prefixDoublingN[int32](x, SentinelFirst, nil, config[0], config[1])
+			checkSuffixArray(t, x, sa)
+		}
+	}
+}
+
+func BenchmarkRadixSortBucketsN(b *testing.B) {
+	x := randomStringN(100000, "acgt", newRandomSeed(b))
+	for _, config := range radixConfigs {
+		b.Run(fmt.Sprintf("%dx%d", config[0], config[1]), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				prefixDoublingN[int32](x, SentinelFirst, nil, config[0], config[1])
+			}
+		})
+	}
+}
+
+func TestCertifySA(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "a", "mississippi", randomStringN(500, "acgt", rng), randomStringN(500, "a", rng)} {
+		sa := PrefixDoubling(x)
+		ok, proof := CertifySA(x, sa)
+		if !ok {
+			t.Fatalf("Expected the suffix array of %q to be certified", x)
+		}
+		for row, pos := range sa {
+			if proof[pos] != int32(row) {
+				t.Fatalf("Expected the inverse suffix array as the proof, got %v", proof)
+			}
+		}
+		if !VerifySACertificate(x, sa, proof) {
+			t.Errorf("Expected the certificate to verify for %q", x)
+		}
+
+		// Permutations that are not sorted.
+		for i := 0; i < 10 && len(x) > 1; i++ {
+			bad := append([]int32{}, sa...)
+			a, b := 1+rng.Intn(len(x)), 1+rng.Intn(len(x))
+			if x[bad[a]:] == x[bad[b]:] {
+				continue
+			}
+			bad[a], bad[b] = bad[b], bad[a]
+			if a != b {
+				if ok, _ := CertifySA(x, bad); ok {
+					t.Errorf("Expected a swap of rows %d and %d to be rejected", a, b)
+				}
+			}
+		}
+	}
+
+	x := "banana"
+	sa := PrefixDoubling(x)
+	if ok, _ := CertifySA(x, []int32{6, 5, 5, 1, 0, 4, 2}); ok {
+		t.Errorf("Expected a non-permutation to be rejected")
+	}
+	if ok, _ := CertifySA(x, sa[:6]); ok {
+		t.Errorf("Expected a short suffix array to be rejected")
+	}
+	_, proof := CertifySA(x, sa)
+	proof[0], proof[1] = proof[1], proof[0]
+	if VerifySACertificate(x, sa, proof) {
+		t.Errorf("Expected a tampered certificate to be rejected")
+	}
+}
+
+func TestPrefixDoublingE(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(1000, "acgt", rng)
+	for _, budget := range []int64{0, PrefixDoublingWorkingSet(len(x))} {
+		sa, err := PrefixDoublingE(x, budget)
+		if err != nil {
+			t.Fatalf("Expected the suffix array within a budget of %d, got %v", budget, err)
+		}
+		checkSuffixArray(t, x, sa)
+	}
+	if sa, err := PrefixDoublingE(x, 100); err == nil || sa != nil {
+		t.Errorf("Expected an error for a tiny budget, got %v", err)
+	}
+}
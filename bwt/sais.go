@@ -0,0 +1,149 @@
+package bwt
+
+import "math"
+
+// SAIS computes the suffix array of x, including the sentinel, with the
+// SA-IS algorithm of Nong, Zhang and Chan, which takes O(n) time. The
+// result is the same as PrefixDoubling's, with sa[0] == len(x), so it can
+// be used in its place.
+//
+// Suffixes are classified as S-type, smaller than the next suffix, or
+// L-type, larger than it, and the leftmost S-type suffixes after an L-type
+// one, the LMS suffixes, are enough to sort all of them: given the LMS
+// suffixes in order, a left-to-right scan over the buckets of first
+// characters induces the order of the L-type suffixes, and a
+// right-to-left scan that of the S-type ones. The LMS suffixes are
+// ordered by sorting the LMS substrings between them the same way,
+// naming them by rank, and, if two substrings share a name, sorting the
+// suffixes of the reduced string of names recursively. The reduced string
+// is at most half as long as the text, so the total time is linear,
+// however repetitive the text is. It panics if x is too long for 32-bit
+// positions.
+func SAIS(x string) []int32 {
+	if len(x) >= math.MaxInt32 {
+		panic("bwt: string too long for 32-bit positions")
+	}
+	rank0, sigma := calcRank0[int32](x)
+	return sais(rank0, int(sigma))
+}
+
+// sais returns the suffix array of s, a string over [0, sigma) whose last
+// symbol is a unique 0.
+func sais(s []int32, sigma int) []int32 {
+	n := len(s)
+	sa := make([]int32, n)
+	if n == 1 {
+		return sa
+	}
+
+	stype := make([]bool, n)
+	stype[n-1] = true
+	for i := n - 2; i >= 0; i-- {
+		stype[i] = s[i] < s[i+1] || (s[i] == s[i+1] && stype[i+1])
+	}
+	isLMS := func(i int) bool {
+		return i > 0 && stype[i] && !stype[i-1]
+	}
+
+	counts := make([]int32, sigma)
+	for _, a := range s {
+		counts[a]++
+	}
+	heads, tails := make([]int32, sigma), make([]int32, sigma)
+	resetBuckets := func() {
+		acc := int32(0)
+		for a, count := range counts {
+			heads[a] = acc
+			acc += count
+			tails[a] = acc
+		}
+	}
+
+	// induce sorts all suffixes from the LMS suffixes in lms, which must
+	// be in suffix order for the result to be right.
+	induce := func(lms []int32) {
+		for i := range sa {
+			sa[i] = -1
+		}
+		resetBuckets()
+		for k := len(lms) - 1; k >= 0; k-- {
+			j := lms[k]
+			tails[s[j]]--
+			sa[tails[s[j]]] = j
+		}
+		for i := 0; i < n; i++ {
+			if j := sa[i] - 1; j >= 0 && !stype[j] {
+				sa[heads[s[j]]] = j
+				heads[s[j]]++
+			}
+		}
+		resetBuckets()
+		for i := n - 1; i >= 0; i-- {
+			if j := sa[i] - 1; j >= 0 && stype[j] {
+				tails[s[j]]--
+				sa[tails[s[j]]] = j
+			}
+		}
+	}
+
+	// Sorting from the LMS suffixes in text order sorts the LMS
+	// substrings, which is enough to name them.
+	lms := []int32{}
+	for i := 1; i < n; i++ {
+		if isLMS(i) {
+			lms = append(lms, int32(i))
+		}
+	}
+	induce(lms)
+
+	// equal reports whether the LMS substrings at a and b are equal, in
+	// their characters and types, up to and including the next LMS
+	// position. The sentinel's substring is unique, so the comparison
+	// always stops inside s.
+	equal := func(a, b int) bool {
+		for k := 0; ; k++ {
+			if s[a+k] != s[b+k] || stype[a+k] != stype[b+k] {
+				return false
+			}
+			if k > 0 {
+				endA, endB := isLMS(a+k), isLMS(b+k)
+				if endA && endB {
+					return true
+				}
+				if endA != endB {
+					return false
+				}
+			}
+		}
+	}
+	names := make([]int32, n)
+	name, prev := int32(-1), -1
+	for _, j := range sa {
+		if !isLMS(int(j)) {
+			continue
+		}
+		if prev < 0 || !equal(prev, int(j)) {
+			name++
+		}
+		names[j], prev = name, int(j)
+	}
+
+	// The reduced string of names, in text order, ends with the
+	// sentinel's name, 0, and its suffixes sort like the LMS suffixes.
+	reduced := make([]int32, len(lms))
+	for k, j := range lms {
+		reduced[k] = names[j]
+	}
+	sorted := make([]int32, len(lms))
+	if int(name)+1 < len(lms) {
+		for k, r := range sais(reduced, int(name)+1) {
+			sorted[k] = lms[r]
+		}
+	} else {
+		for k, r := range reduced {
+			sorted[r] = lms[k]
+		}
+	}
+	induce(sorted)
+	return sa
+}
@@ -0,0 +1,52 @@
+package bwt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSAIS(t *testing.T) {
+	texts := []string{"", "a", "aa", "aaaa", "ba", "mississippi", "abcabcabc", strings.Repeat("a", 1000)}
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		texts = append(texts, randomStringN(rng.Intn(200), "acgt", rng))
+		// Repeats with a few changes give reduced strings with repeated
+		// names, several levels of recursion deep.
+		x := []byte(strings.Repeat(randomStringN(1+rng.Intn(8), "ab", rng), 2+rng.Intn(100)))
+		if len(x) > 0 && i%2 == 0 {
+			x[rng.Intn(len(x))] = 'c'
+		}
+		texts = append(texts, string(x))
+	}
+
+	for _, x := range texts {
+		sa := SAIS(x)
+		checkSuffixArray(t, x, sa)
+		if expected := PrefixDoubling(x); !reflect.DeepEqual(sa, expected) {
+			t.Errorf("Expected the suffix array %v of %q, got %v", expected, x, sa)
+		}
+	}
+}
+
+func BenchmarkSAIS(b *testing.B) {
+	rng := newRandomSeed(b)
+	x := randomStringN(1<<20, "acgt", rng)
+	repetitive := strings.Repeat(randomStringN(1000, "acgt", rng), 1<<10)
+	for _, bench := range []struct {
+		name string
+		x    string
+		sa   func(string) []int32
+	}{
+		{"SAIS/random", x, SAIS},
+		{"PrefixDoubling/random", x, PrefixDoubling},
+		{"SAIS/repetitive", repetitive, SAIS},
+		{"PrefixDoubling/repetitive", repetitive, PrefixDoubling},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bench.sa(bench.x)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package bwt
+
+// saSamples holds samples of the suffix array and of the inverse suffix
+// array, for locating and extracting without the full suffix array or the
+// text. The suffix array is sampled at the rows whose suffix starts at a
+// multiple of the rate, and the inverse suffix array at the text
+// positions that are multiples of the rate.
+type saSamples struct {
+	rate    int
+	marked  *Bitvector // the rows with a suffix-array sample
+	samples []int32    // the sampled suffix-array values, in row order
+	isa     []int32    // isa[j] is the row of the suffix at j*rate
+}
+
+// lfFunc maps a row to the row of the suffix one position earlier in the
+// text, and returns the symbol between them.
+type lfFunc func(i int) (int, byte)
+
+func newSASamples(sa []int32, rate int) *saSamples {
+	if rate < 1 {
+		rate = 1
+	}
+	s := &saSamples{
+		rate:   rate,
+		marked: newBitvector(len(sa)),
+		isa:    make([]int32, (len(sa)-1)/rate+1),
+	}
+	for row, pos := range sa {
+		if int(pos)%rate == 0 {
+			s.marked.set(row)
+			s.samples = append(s.samples, pos)
+			s.isa[int(pos)/rate] = int32(row)
+		}
+	}
+	s.marked.finish()
+	return s
+}
+
+// newSASamplesFromLF samples the suffix array of a BWT of length n like
+// newSASamples, without the suffix array: it walks lf from row 0, the
+// sentinel suffix, through the suffixes in decreasing order of position.
+func newSASamplesFromLF(n, rate int, lf lfFunc) *saSamples {
+	if rate < 1 {
+		rate = 1
+	}
+	s := &saSamples{
+		rate:   rate,
+		marked: newBitvector(n),
+		isa:    make([]int32, (n-1)/rate+1),
+	}
+	for i, pos := 0, n-1; ; pos-- {
+		if pos%rate == 0 {
+			s.marked.set(i)
+			s.isa[pos/rate] = int32(i)
+		}
+		if pos == 0 {
+			break
+		}
+		i, _ = lf(i)
+	}
+	s.marked.finish()
+	s.samples = make([]int32, s.marked.Ones())
+	for pos, row := range s.isa {
+		s.samples[s.marked.Rank(int(row))] = int32(pos * rate)
+	}
+	return s
+}
+
+// position returns the suffix-array value of row i, walking LF until it
+// reaches a sampled row.
+func (s *saSamples) position(i int, lf lfFunc) int32 {
+	steps := int32(0)
+	for !s.marked.Get(i) {
+		i, _ = lf(i)
+		steps++
+	}
+	return s.samples[s.marked.Rank(i)] + steps
+}
+
+// positions returns the suffix-array values of the rows [L, R) like
+// position, but walks all the rows in lockstep: each round takes one LF
+// step for every row that has not reached a sample yet. The rows of an
+// interval share their suffixes' prefixes, so after each step the rows
+// with the same preceding symbol are still close together, and the
+// lookups of a round hit nearby entries of the O-table instead of
+// jumping between a walk for each row.
+func (s *saSamples) positions(L, R int, lf lfFunc) []int32 {
+	positions := make([]int32, R-L)
+	rows := make([]int, 0, R-L)    // the rows still walking
+	pending := make([]int, 0, R-L) // and the positions they are for
+	for k := range positions {
+		if row := L + k; s.marked.Get(row) {
+			positions[k] = s.samples[s.marked.Rank(row)]
+		} else {
+			rows = append(rows, row)
+			pending = append(pending, k)
+		}
+	}
+	for steps := int32(1); len(rows) > 0; steps++ {
+		keep := 0
+		for j, row := range rows {
+			row, _ = lf(row)
+			if s.marked.Get(row) {
+				positions[pending[j]] = s.samples[s.marked.Rank(row)] + steps
+			} else {
+				rows[keep], pending[keep] = row, pending[j]
+				keep++
+			}
+		}
+		rows, pending = rows[:keep], pending[:keep]
+	}
+	return positions
+}
+
+// extract returns the symbols of x[i:j], for a text of length n, walking
+// backwards from the first inverse suffix-array sample at or after j, or
+// from the sentinel's row, 0, if there is none.
+func (s *saSamples) extract(i, j, n int, lf lfFunc) []byte {
+	if i < 0 || j > n || i > j {
+		panic("bwt: extract range out of bounds")
+	}
+
+	start, row := n, 0
+	if k := (j + s.rate - 1) / s.rate; k < len(s.isa) {
+		start, row = k*s.rate, int(s.isa[k])
+	}
+
+	y := make([]byte, j-i)
+	var a byte
+	for pos := start; pos > i; pos-- {
+		row, a = lf(row)
+		if pos <= j {
+			y[pos-1-i] = a
+		}
+	}
+	return y
+}
+
+// sizeBytes returns the size of the samples' payload in bytes.
+func (s *saSamples) sizeBytes() int {
+	return s.marked.SizeBytes() + (len(s.samples)+len(s.isa))*int32Size
+}
+
+// SampledSA holds the suffix array of an FMIndex sampled every d text
+// positions, for locating with O(n/d) space for the samples instead of
+// the full suffix array. The rows with a sample are marked in a
+// bitvector over the rows, and the position of an unmarked row is found
+// by LF-mapping until a marked row is reached and adding the number of
+// steps to its sample, at most d-1 of them. A SampledSA shares the BWT and
+// tables of the index it was built from, but not its suffix array, so the
+// FMIndex can be dropped once the samples are taken.
+type SampledSA struct {
+	bwt     []byte
+	alpha   *Alphabet
+	ctab    *CTab
+	otab    rankTable
+	samples *saSamples
+}
+
+// NewSampledSA samples the suffix array of idx every d text positions. A d
+// below 1 samples every position.
+func NewSampledSA(idx *FMIndex, d int) *SampledSA {
+	return &SampledSA{
+		bwt:     idx.bwt,
+		alpha:   idx.alpha,
+		ctab:    idx.ctab,
+		otab:    idx.otab,
+		samples: newSASamples(idx.sa, d),
+	}
+}
+
+// SizeBytes returns the size of the samples and their bitvector in bytes,
+// not counting the shared BWT and tables.
+func (s *SampledSA) SizeBytes() int {
+	return s.samples.sizeBytes()
+}
+
+// lf returns the row of the suffix one position before the suffix in row
+// i, and the symbol between them.
+func (s *SampledSA) lf(i int) (int, byte) {
+	a := s.bwt[i]
+	return s.ctab.Rank(a) + s.otab.Rank(a, i), a
+}
+
+// LocateSampled returns the positions where p occurs in the text, the same
+// positions, in the same suffix-array order, as the FMIndex's Locate, but
+// recovered from the samples.
+func LocateSampled(p string, s *SampledSA) []int {
+	return locateSampled(p, s)
+}
+
+// LocateSampledBytes is LocateSampled for a pattern given as a byte slice.
+func LocateSampledBytes(p []byte, s *SampledSA) []int {
+	return locateSampled(p, s)
+}
+
+func locateSampled[P pattern](p P, s *SampledSA) []int {
+	L, R := backwardSearch(p, len(s.bwt), s.alpha, s.ctab, s.otab)
+	positions := make([]int, 0, R-L)
+	for _, pos := range s.samples.positions(L, R, s.lf) {
+		if int(pos) != len(s.bwt)-1 {
+			positions = append(positions, int(pos))
+		}
+	}
+	return positions
+}
@@ -0,0 +1,68 @@
+package bwt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSampledPositions(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "mississippi", randomStringN(1000, "acgt", rng), strings.Repeat("ab", 300)} {
+		idx := BuildIndexCompact(x)
+		for _, rows := range [][2]int{{0, 0}, {0, 1}, {0, len(idx.bwt)}, {len(idx.bwt) / 3, len(idx.bwt) / 2}} {
+			L, R := rows[0], rows[1]
+			expected := make([]int32, 0, R-L)
+			for i := L; i < R; i++ {
+				expected = append(expected, idx.samples.position(i, idx.lf))
+			}
+			if got := idx.samples.positions(L, R, idx.lf); !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected positions %v of rows [%d, %d), got %v", expected, L, R, got)
+			}
+		}
+	}
+}
+
+func TestLocateSampled(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(rng.Intn(300), "acgt", rng)
+		fm := NewFMIndex(x)
+		for _, d := range []int{0, 1, 4, 32, 1000} {
+			s := NewSampledSA(fm, d)
+			if d >= 4 && len(x) >= 100 && s.SizeBytes() >= len(x)*int32Size {
+				t.Errorf("Expected the samples at rate %d to be smaller than the suffix array, got %d bytes", d, s.SizeBytes())
+			}
+			for j := 0; j < 10; j++ {
+				p := randomStringN(rng.Intn(4), "acgt", rng)
+				expected := fm.Locate(p)
+				if positions := LocateSampled(p, s); !reflect.DeepEqual(positions, expected) {
+					t.Errorf("Expected %q at %v in %q with rate %d, got %v", p, expected, x, d, positions)
+				}
+				if positions := LocateSampledBytes([]byte(p), s); !reflect.DeepEqual(positions, expected) {
+					t.Errorf("Expected %q as bytes at %v in %q with rate %d, got %v", p, expected, x, d, positions)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkSampledPositions compares walking each row of a large interval
+// to a sample on its own with walking all of them in lockstep.
+func BenchmarkSampledPositions(b *testing.B) {
+	x := randomStringN(1<<20, "ab", newRandomSeed(b))
+	idx := BuildIndexCompact(x)
+	L, R := idx.Search("ab")
+	b.Run("PerRow", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for row := L; row < R; row++ {
+				idx.samples.position(row, idx.lf)
+			}
+		}
+	})
+	b.Run("Batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			idx.samples.positions(L, R, idx.lf)
+		}
+	})
+}
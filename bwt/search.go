@@ -0,0 +1,432 @@
+package bwt
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// sortedLocate returns the positions of p sorted by position.
+func sortedLocate[P pattern](index *Index, p P) []int32 {
+	positions := index.positions(search(index, p))
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+	return positions
+}
+
+// CoOccur returns the pairs of positions (i, j) where p1 occurs at i and
+// p2 at j, and the two start at most maxGap apart, |i-j| <= maxGap. The
+// pairs are sorted by i and then j. Both patterns are located, sorted and
+// merged with two pointers, so the time is O(occ log occ) plus the number
+// of pairs.
+func CoOccur(index *Index, p1, p2 string, maxGap int) [][2]int32 {
+	return coOccur(index, p1, p2, maxGap)
+}
+
+// CoOccurBytes is CoOccur for patterns given as byte slices.
+func CoOccurBytes(index *Index, p1, p2 []byte, maxGap int) [][2]int32 {
+	return coOccur(index, p1, p2, maxGap)
+}
+
+func coOccur[P pattern](index *Index, p1, p2 P, maxGap int) [][2]int32 {
+	pairs := [][2]int32{}
+	first, second := sortedLocate(index, p1), sortedLocate(index, p2)
+	// No two positions are further apart than the text is long, so a
+	// larger gap is clamped, and the bounds are compared as ints, where
+	// they cannot overflow.
+	if maxGap > index.Len() {
+		maxGap = index.Len()
+	}
+
+	lo := 0
+	for _, i := range first {
+		for lo < len(second) && int(second[lo]) < int(i)-maxGap {
+			lo++
+		}
+		for k := lo; k < len(second) && int(second[k]) <= int(i)+maxGap; k++ {
+			pairs = append(pairs, [2]int32{i, second[k]})
+		}
+	}
+	return pairs
+}
+
+// ConcatMatch returns the positions where p1 is immediately followed by
+// p2, that is, the occurrences pos of p1 where p2 starts at pos+len(p1),
+// sorted by position. These are exactly the occurrences of the
+// concatenation p1+p2: an occurrence of p1 at pos followed by p2 spells
+// p1+p2 from pos, and an occurrence of p1+p2 at pos has p1 at pos and p2
+// right after it. So a single backward search for p1+p2 finds them,
+// without locating both patterns and intersecting the positions.
+func ConcatMatch(index *Index, p1, p2 string) []int32 {
+	return concatMatch(index, p1, p2)
+}
+
+// ConcatMatchBytes is ConcatMatch for patterns given as byte slices.
+func ConcatMatchBytes(index *Index, p1, p2 []byte) []int32 {
+	return concatMatch(index, p1, p2)
+}
+
+func concatMatch[P pattern](index *Index, p1, p2 P) []int32 {
+	p := make([]byte, 0, len(p1)+len(p2))
+	p = append(append(p, p1...), p2...)
+	return sortedLocate(index, p)
+}
+
+// Coverage returns, for each position in the text, the number of
+// occurrences of the patterns that cover it. Each occurrence adds one at
+// its start and subtracts one after its end in a difference array, so
+// the time is linear in the text length plus the number of occurrences.
+func Coverage(index *Index, patterns []string) []int {
+	return coverage(index, patterns)
+}
+
+// CoverageBytes is Coverage for patterns given as byte slices.
+func CoverageBytes(index *Index, patterns [][]byte) []int {
+	return coverage(index, patterns)
+}
+
+func coverage[P pattern](index *Index, patterns []P) []int {
+	n := index.Len()
+	diff := make([]int, n+1)
+	for _, p := range patterns {
+		if len(p) == 0 {
+			continue // the empty pattern covers nothing
+		}
+		for _, pos := range index.positions(search(index, p)) {
+			diff[pos]++
+			diff[int(pos)+len(p)]--
+		}
+	}
+
+	coverage := make([]int, n)
+	acc := 0
+	for i := range coverage {
+		acc += diff[i]
+		coverage[i] = acc
+	}
+	return coverage
+}
+
+// PrecedingChars returns how often each character occurs immediately
+// before an occurrence of p. Those characters are the BWT entries in p's
+// suffix-array interval, so each is counted with one range rank per
+// character of the alphabet, in O(sigma) time after the search, however
+// many occurrences there are. An occurrence at the start of the text has
+// the sentinel before it and is left out, and characters that never
+// precede p are not in the map.
+func PrecedingChars(index *Index, p string) map[byte]int {
+	return precedingChars(index, p)
+}
+
+// PrecedingCharsBytes is PrecedingChars for a pattern given as a byte
+// slice.
+func PrecedingCharsBytes(index *Index, p []byte) map[byte]int {
+	return precedingChars(index, p)
+}
+
+func precedingChars[P pattern](index *Index, p P) map[byte]int {
+	counts := map[byte]int{}
+	L, R := search(index, p)
+	if L >= R {
+		return counts
+	}
+	for a := byte(1); int(a) < index.alpha.Size(); a++ {
+		if count := index.otab.RangeRank(a, L, R); count > 0 {
+			counts[index.alpha.letters[a]] = count
+		}
+	}
+	return counts
+}
+
+// FollowingChars returns how often each character occurs immediately
+// after an occurrence of p, the counterpart of PrecedingChars. Unlike the
+// preceding characters, the following ones are not in the BWT, so p is
+// extended on the right instead: the occurrences of p followed by c are
+// those of pc, and a backward search for pc for each character c of the
+// alphabet counts them, in O(sigma*|p|) time however many occurrences
+// there are, also on a compact index. An occurrence at the end of the text
+// has only the sentinel after it and is left out, and characters that
+// never follow p are not in the map.
+func FollowingChars(index *Index, p string) map[byte]int {
+	return followingChars(index, p)
+}
+
+// FollowingCharsBytes is FollowingChars for a pattern given as a byte
+// slice.
+func FollowingCharsBytes(index *Index, p []byte) map[byte]int {
+	return followingChars(index, p)
+}
+
+func followingChars[P pattern](index *Index, p P) map[byte]int {
+	counts := map[byte]int{}
+	if L, R := search(index, p); L >= R {
+		return counts
+	}
+	for a := byte(1); int(a) < index.alpha.Size(); a++ {
+		// The search for p, started from the interval of a.
+		L, R := index.ctab.Rank(a), index.ctab.Rank(a)+index.otab.RangeRank(a, 0, len(index.bwt))
+		for i := len(p) - 1; i >= 0 && L < R; i-- {
+			L, R = index.extend(p[i], L, R)
+		}
+		if R > L {
+			counts[index.alpha.letters[a]] = R - L
+		}
+	}
+	return counts
+}
+
+// CountPalindromicOccurrences counts the occurrences of p if p is a
+// palindrome, i.e., reads the same forwards and backwards, and returns 0
+// otherwise. For restriction-site-style reverse-complement palindromes,
+// compare p with ReverseComplement(p) instead.
+func CountPalindromicOccurrences(index *Index, p string) int {
+	return countPalindromic(index, p)
+}
+
+// CountPalindromicOccurrencesBytes is CountPalindromicOccurrences for a
+// pattern given as a byte slice.
+func CountPalindromicOccurrencesBytes(index *Index, p []byte) int {
+	return countPalindromic(index, p)
+}
+
+func countPalindromic[P pattern](index *Index, p P) int {
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		if p[i] != p[j] {
+			return 0
+		}
+	}
+	L, R := search(index, p)
+	return R - L
+}
+
+// CountSuffixMatch returns 1 if p is a suffix of the text and 0
+// otherwise: the number of occurrences of p that end right before the
+// sentinel. Backward search starts from the sentinel's own row, 0,
+// instead of the whole suffix array, so it finds the interval of p
+// followed by the sentinel, which holds at most the one row.
+func CountSuffixMatch(index *Index, p string) int {
+	return countSuffixMatch(index, p)
+}
+
+// CountSuffixMatchBytes is CountSuffixMatch for a pattern given as a byte
+// slice.
+func CountSuffixMatchBytes(index *Index, p []byte) int {
+	return countSuffixMatch(index, p)
+}
+
+func countSuffixMatch[P pattern](index *Index, p P) int {
+	if len(p) > index.Len() {
+		return 0
+	}
+	L, R := 0, 1
+	for i := len(p) - 1; i >= 0 && L < R; i-- {
+		L, R = index.extend(p[i], L, R)
+	}
+	return R - L
+}
+
+// CountCharClasses counts the occurrences of a degenerate pattern, where
+// classes[i] is the set of bytes allowed at position i of the pattern.
+// Backward search branches on every member of a class, and since distinct
+// strings have disjoint intervals, the counts of the branches add up.
+// Duplicate members of a class are counted once.
+func CountCharClasses(index *Index, classes [][]byte) int {
+	return classSearch(index, classes, nil)
+}
+
+// classSearch runs the branching backward search of CountCharClasses and
+// returns the number of occurrences of classes. If visit is not nil, it
+// is called for each member classes[i][j] that was searched, with the
+// number of occurrences that have it at position i. Duplicate members
+// are searched once, for their first copy.
+func classSearch(index *Index, classes [][]byte, visit func(i, j, count int)) int {
+	if len(classes) > index.Len() {
+		return 0
+	}
+	var search func(i, L, R int) int
+	search = func(i, L, R int) int {
+		if L >= R {
+			return 0
+		}
+		if i < 0 {
+			return R - L
+		}
+		total := 0
+		var seen [256]bool
+		for j, a := range classes[i] {
+			if !seen[a] {
+				seen[a] = true
+				l, r := index.extend(a, L, R)
+				count := search(i-1, l, r)
+				if visit != nil {
+					visit(i, j, count)
+				}
+				total += count
+			}
+		}
+		return total
+	}
+	return search(len(classes)-1, 0, len(index.bwt))
+}
+
+// CountWildcardBounded counts the occurrences of p, where the byte
+// wildcard matches any character of the text, like CountCharClasses with
+// the whole alphabet at the wildcard positions. Every wildcard multiplies
+// the branches of the search by up to the alphabet size, and a run of
+// them does so without any interval narrowing in between, so patterns
+// with more than maxConsecutive wildcards in a row are rejected with an
+// error rather than searched.
+func CountWildcardBounded(index *Index, p string, wildcard byte, maxConsecutive int) (int, error) {
+	return countWildcardBounded(index, p, wildcard, maxConsecutive)
+}
+
+// CountWildcardBoundedBytes is CountWildcardBounded for a pattern given as
+// a byte slice.
+func CountWildcardBoundedBytes(index *Index, p []byte, wildcard byte, maxConsecutive int) (int, error) {
+	return countWildcardBounded(index, p, wildcard, maxConsecutive)
+}
+
+func countWildcardBounded[P pattern](index *Index, p P, wildcard byte, maxConsecutive int) (int, error) {
+	letters := index.alpha.letters[1:] // every character of the text
+	classes := make([][]byte, len(p))
+	run := 0
+	for i := 0; i < len(p); i++ {
+		if p[i] != wildcard {
+			run = 0
+			classes[i] = []byte{p[i]}
+			continue
+		}
+		if run++; run > maxConsecutive {
+			return 0, fmt.Errorf("more than %d consecutive wildcards at index %d", maxConsecutive, i-run+1)
+		}
+		classes[i] = letters
+	}
+	return CountCharClasses(index, classes), nil
+}
+
+// CountProfile counts the occurrences of a profile, a degenerate pattern
+// where profile[i] is the set of bytes allowed at position i, like
+// CountCharClasses, but breaks the count down by position: counts[i][j]
+// is the number of occurrences with profile[i][j] at position i, so each
+// row sums to the total number of occurrences. The counts of a duplicate
+// member of a position are all given to its first copy.
+func CountProfile(index *Index, profile [][]byte) (counts [][]int) {
+	counts = make([][]int, len(profile))
+	for i := range profile {
+		counts[i] = make([]int, len(profile[i]))
+	}
+	classSearch(index, profile, func(i, j, count int) {
+		counts[i][j] += count
+	})
+	return counts
+}
+
+// RangeCount counts the occurrences of p that start in [textLo, textHi).
+// The suffix array is held in a wavelet tree, built the first time
+// RangeCount is used with the index, so the count takes O(log n) time
+// for the range query on the pattern's suffix-array interval, instead of
+// locating and filtering all occurrences.
+func RangeCount(index *Index, p string, textLo, textHi int32) int {
+	return rangeCount(index, p, textLo, textHi)
+}
+
+// RangeCountBytes is RangeCount for a pattern given as a byte slice.
+func RangeCountBytes(index *Index, p []byte, textLo, textHi int32) int {
+	return rangeCount(index, p, textLo, textHi)
+}
+
+func rangeCount[P pattern](index *Index, p P, textLo, textHi int32) int {
+	L, R := search(index, p)
+	if L >= R {
+		return 0
+	}
+	index.saTreeOnce.Do(func() {
+		index.saTree = newWaveletMatrix(index.positions(0, len(index.bwt)))
+	})
+	return index.saTree.rangeCount(L, R, textLo, textHi)
+}
+
+// AllSuffixCounts returns the number of occurrences of every suffix of p,
+// mapping each start offset i in p to the count of p[i:]. Backward search
+// processes the suffixes of p from the shortest, so a single search gives
+// them all: the width of the interval after each step is the count of the
+// suffix processed so far. Once the interval is empty, all longer
+// suffixes have count 0 and the search stops extending.
+func AllSuffixCounts(index *Index, p string) map[int]int {
+	return allSuffixCounts(index, p)
+}
+
+// AllSuffixCountsBytes is AllSuffixCounts for a pattern given as a byte
+// slice.
+func AllSuffixCountsBytes(index *Index, p []byte) map[int]int {
+	return allSuffixCounts(index, p)
+}
+
+func allSuffixCounts[P pattern](index *Index, p P) map[int]int {
+	counts := make(map[int]int, len(p))
+	L, R := 0, len(index.bwt)
+	for i := len(p) - 1; i >= 0; i-- {
+		if L < R {
+			L, R = index.extend(p[i], L, R)
+		}
+		counts[i] = R - L
+	}
+	return counts
+}
+
+// ScoredMatch is an occurrence of a scored pattern at text position Pos.
+type ScoredMatch struct {
+	Pos   int32
+	Score float64
+}
+
+// ScoreOccurrences finds the windows of the text that score at least
+// threshold against a position weight matrix, where profile[i][c] is the
+// score, such as a log-odds score, of character c at position i of the
+// window, and characters beyond the end of profile[i] are not allowed
+// there. The matches are sorted by position.
+//
+// The windows are found by backward search from the last position of the
+// profile, branching over the characters that occur, and a branch is cut
+// as soon as its score plus the best possible score of the positions
+// still to come falls below the threshold, so only windows that can still
+// reach it are searched.
+func ScoreOccurrences(index *Index, profile [][]float64, threshold float64) []ScoredMatch {
+	matches := []ScoredMatch{}
+	m := len(profile)
+	if m > index.Len() {
+		return matches
+	}
+	// best[i] is the highest score the positions [0, i) can add.
+	best := make([]float64, m+1)
+	for i, scores := range profile {
+		top := math.Inf(-1)
+		for _, s := range scores {
+			if s > top {
+				top = s
+			}
+		}
+		best[i+1] = best[i] + top
+	}
+
+	var search func(i, L, R int, score float64)
+	search = func(i, L, R int, score float64) {
+		if L >= R || score+best[i+1] < threshold {
+			return
+		}
+		if i < 0 {
+			for _, pos := range index.positions(L, R) {
+				matches = append(matches, ScoredMatch{pos, score})
+			}
+			return
+		}
+		for a := byte(1); int(a) < index.alpha.Size(); a++ {
+			if c := int(index.alpha.letters[a]); c < len(profile[i]) {
+				l, r := extendInterval(a, L, R, index.ctab, index.otab)
+				search(i-1, l, r, score+profile[i][c])
+			}
+		}
+	}
+	search(m-1, 0, len(index.bwt), 0)
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Pos < matches[j].Pos })
+	return matches
+}
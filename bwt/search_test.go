@@ -0,0 +1,421 @@
+package bwt
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoOccur(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := randomStringN(60, "acgt", rng)
+		p1, p2 := randomStringN(2, "acgt", rng), randomStringN(rng.Intn(3)+1, "acgt", rng)
+		gap := rng.Intn(10)
+
+		expected := [][2]int32{}
+		for _, a := range naiveOccurrences(x, p1) {
+			for _, b := range naiveOccurrences(x, p2) {
+				if a-b <= int32(gap) && b-a <= int32(gap) {
+					expected = append(expected, [2]int32{a, b})
+				}
+			}
+		}
+
+		pairs := CoOccur(BuildIndex(x), p1, p2, gap)
+		if len(pairs) != len(expected) {
+			t.Fatalf("CoOccur(%q, %q, %d) in %q: got %v, expected %v", p1, p2, gap, x, pairs, expected)
+		}
+		for j := range pairs {
+			if pairs[j] != expected[j] {
+				t.Fatalf("CoOccur(%q, %q, %d) in %q: got %v, expected %v", p1, p2, gap, x, pairs, expected)
+			}
+		}
+	}
+
+	// A gap beyond int32 pairs everything.
+	if pairs := CoOccur(BuildIndex("abab"), "a", "b", math.MaxInt); len(pairs) != 4 {
+		t.Errorf("Expected all 4 pairs for an unbounded gap, got %v", pairs)
+	}
+}
+
+func TestConcatMatch(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := randomStringN(100, "acgt", rng)
+		idx := BuildIndex(x)
+		p1, p2 := randomStringN(rng.Intn(3), "acgt", rng), randomStringN(rng.Intn(3), "acgt", rng)
+
+		expected := []int32{}
+		for _, pos := range naiveOccurrences(x, p1) {
+			if strings.HasPrefix(x[int(pos)+len(p1):], p2) {
+				expected = append(expected, pos)
+			}
+		}
+		if matches := ConcatMatch(idx, p1, p2); !reflect.DeepEqual(matches, expected) {
+			t.Errorf("Expected ConcatMatch(%q, %q) = %v in %q, got %v", p1, p2, expected, x, matches)
+		}
+		if matches := ConcatMatchBytes(idx, []byte(p1), []byte(p2)); !reflect.DeepEqual(matches, expected) {
+			t.Errorf("Expected ConcatMatchBytes(%q, %q) = %v in %q, got %v", p1, p2, expected, x, matches)
+		}
+		if concat := sortedLocate(idx, p1+p2); !reflect.DeepEqual(concat, expected) {
+			t.Errorf("Expected the occurrences of %q to be %v, got %v", p1+p2, expected, concat)
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := randomStringN(50, "acgt", rng)
+		patterns := []string{""}
+		for j := 0; j < 4; j++ {
+			patterns = append(patterns, randomStringN(1+rng.Intn(3), "acgt", rng))
+		}
+
+		expected := make([]int, len(x))
+		for _, p := range patterns {
+			for _, pos := range naiveOccurrences(x, p) {
+				for j := int(pos); j < int(pos)+len(p); j++ {
+					expected[j]++
+				}
+			}
+		}
+
+		coverage := Coverage(BuildIndex(x), patterns)
+		if len(coverage) != len(x) {
+			t.Fatalf("Expected coverage of length %d, got %d", len(x), len(coverage))
+		}
+		for j := range coverage {
+			if coverage[j] != expected[j] {
+				t.Fatalf("Coverage of %v in %q: got %v, expected %v", patterns, x, coverage, expected)
+			}
+		}
+	}
+}
+
+func TestPrecedingChars(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(1+rng.Intn(200), "acgt", rng)
+		idx := BuildIndex(x)
+		for j := 0; j < 10; j++ {
+			p := randomStringN(rng.Intn(3), "acgt", rng)
+			if j == 0 {
+				p = x[:1+rng.Intn(len(x))] // occurs at the start
+			}
+			expected := map[byte]int{}
+			for _, pos := range naiveOccurrences(x, p) {
+				if pos > 0 {
+					expected[x[pos-1]]++
+				}
+			}
+			if counts := PrecedingChars(idx, p); !reflect.DeepEqual(counts, expected) {
+				t.Errorf("Expected %v before %q in %q, got %v", expected, p, x, counts)
+			}
+			if counts := PrecedingCharsBytes(idx, []byte(p)); !reflect.DeepEqual(counts, expected) {
+				t.Errorf("Expected %v before %q as bytes in %q, got %v", expected, p, x, counts)
+			}
+		}
+	}
+}
+
+func TestFollowingChars(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(1+rng.Intn(200), "acgt", rng)
+		for _, idx := range []*Index{BuildIndex(x), BuildIndexCompact(x)} {
+			for j := 0; j < 10; j++ {
+				p := randomStringN(rng.Intn(3), "acgtx", rng)
+				if j == 0 {
+					p = x[rng.Intn(len(x)):] // occurs at the end
+				}
+				expected := map[byte]int{}
+				for _, pos := range naiveOccurrences(x, p) {
+					if end := int(pos) + len(p); end < len(x) {
+						expected[x[end]]++
+					}
+				}
+				if counts := FollowingChars(idx, p); !reflect.DeepEqual(counts, expected) {
+					t.Errorf("Expected %v after %q in %q, got %v", expected, p, x, counts)
+				}
+				if counts := FollowingCharsBytes(idx, []byte(p)); !reflect.DeepEqual(counts, expected) {
+					t.Errorf("Expected %v after %q as bytes in %q, got %v", expected, p, x, counts)
+				}
+			}
+		}
+	}
+}
+
+func TestCountPalindromicOccurrences(t *testing.T) {
+	idx := BuildIndex("abacabadabacaba")
+	for _, test := range []struct {
+		p        string
+		expected int
+	}{
+		{"aba", 4},
+		{"abacaba", 2},
+		{"d", 1},
+		{"", 16},
+		{"ab", 0},
+		{"abac", 0},
+		{"aca", 2},
+		{"xyx", 0},
+	} {
+		if count := CountPalindromicOccurrences(idx, test.p); count != test.expected {
+			t.Errorf("Expected %d palindromic occurrences of %q, got %d", test.expected, test.p, count)
+		}
+		if count := CountPalindromicOccurrencesBytes(idx, []byte(test.p)); count != test.expected {
+			t.Errorf("Expected %d palindromic occurrences of %q as bytes, got %d", test.expected, test.p, count)
+		}
+	}
+}
+
+func TestCountSuffixMatch(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(200, "acgt", rng)
+	idx := BuildIndex(x)
+	for i := 0; i < 50; i++ {
+		p := randomStringN(rng.Intn(4), "acgt", rng)
+		if i%2 == 0 {
+			p = x[len(x)-rng.Intn(10):]
+		}
+		expected := 0
+		if strings.HasSuffix(x, p) {
+			expected = 1
+		}
+		if count := CountSuffixMatch(idx, p); count != expected {
+			t.Errorf("Expected CountSuffixMatch(%q) = %d, got %d", p, expected, count)
+		}
+		if count := CountSuffixMatchBytes(idx, []byte(p)); count != expected {
+			t.Errorf("Expected CountSuffixMatchBytes(%q) = %d, got %d", p, expected, count)
+		}
+	}
+
+	idx = BuildIndex("abab")
+	for p, expected := range map[string]int{"": 1, "b": 1, "ab": 1, "abab": 1, "a": 0, "aba": 0, "babab": 0, "x": 0} {
+		if count := CountSuffixMatch(idx, p); count != expected {
+			t.Errorf("Expected CountSuffixMatch(%q) = %d in abab, got %d", p, expected, count)
+		}
+	}
+}
+
+func TestCountCharClasses(t *testing.T) {
+	matches := func(x string, i int, classes [][]byte) bool {
+		for k, class := range classes {
+			if bytes.IndexByte(class, x[i+k]) < 0 {
+				return false
+			}
+		}
+		return true
+	}
+	naive := func(x string, classes [][]byte) int {
+		count := 0
+		for i := 0; i+len(classes) <= len(x); i++ {
+			if matches(x, i, classes) {
+				count++
+			}
+		}
+		return count
+	}
+
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(200, "acgt", rng)
+		idx := BuildIndex(x)
+		for _, classes := range [][][]byte{
+			{[]byte("ac"), []byte("gt"), []byte("g")},
+			{[]byte("acgt"), []byte("a")},
+			{[]byte("aa"), []byte("cxc")},
+			{[]byte("a"), {}, []byte("g")},
+			{},
+		} {
+			if count, expected := CountCharClasses(idx, classes), naive(x, classes); count != expected {
+				t.Errorf("Expected %d occurrences of %q in %q, got %d", expected, classes, x, count)
+			}
+		}
+	}
+
+	if count := CountCharClasses(BuildIndex("ac"), [][]byte{[]byte("a"), []byte("c"), []byte("g")}); count != 0 {
+		t.Errorf("Expected no matches for a pattern longer than the text, got %d", count)
+	}
+}
+
+func TestCountWildcardBounded(t *testing.T) {
+	naive := func(x, p string) int {
+		count := 0
+	scan:
+		for i := 0; i+len(p) <= len(x); i++ {
+			for j := range p {
+				if p[j] != '.' && p[j] != x[i+j] {
+					continue scan
+				}
+			}
+			count++
+		}
+		return count
+	}
+
+	rng := newRandomSeed(t)
+	x := randomStringN(300, "acgt", rng)
+	idx := BuildIndex(x)
+	for _, p := range []string{"a.g", "..t", "a..c.", "", "acgt", "x.a", "."} {
+		count, err := CountWildcardBounded(idx, p, '.', 2)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", p, err)
+		}
+		if expected := naive(x, p); count != expected {
+			t.Errorf("Expected %d occurrences of %q, got %d", expected, p, count)
+		}
+		if bcount, _ := CountWildcardBoundedBytes(idx, []byte(p), '.', 2); bcount != count {
+			t.Errorf("Expected %d occurrences of %q as bytes, got %d", count, p, bcount)
+		}
+	}
+
+	for _, p := range []string{"a...c", "...", "ac...."} {
+		if _, err := CountWildcardBounded(idx, p, '.', 2); err == nil {
+			t.Errorf("Expected an error for %q with at most 2 consecutive wildcards", p)
+		}
+	}
+}
+
+func TestCountProfile(t *testing.T) {
+	naive := func(x string, profile [][]byte) [][]int {
+		counts := make([][]int, len(profile))
+		for i := range profile {
+			counts[i] = make([]int, len(profile[i]))
+		}
+	scan:
+		for pos := 0; pos+len(profile) <= len(x); pos++ {
+			js := make([]int, len(profile))
+			for i, allowed := range profile {
+				if js[i] = bytes.IndexByte(allowed, x[pos+i]); js[i] < 0 {
+					continue scan
+				}
+			}
+			for i, j := range js {
+				counts[i][j]++
+			}
+		}
+		return counts
+	}
+
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(200, "acgt", rng)
+		idx := BuildIndex(x)
+		for _, profile := range [][][]byte{
+			{[]byte("ac"), []byte("gt"), []byte("g")},
+			{[]byte("acgt"), []byte("a"), []byte("ta")},
+			{[]byte("aa"), []byte("cxc")},
+			{[]byte("a"), {}, []byte("g")},
+			{},
+		} {
+			if counts, expected := CountProfile(idx, profile), naive(x, profile); !reflect.DeepEqual(counts, expected) {
+				t.Errorf("Expected counts %v for %q in %q, got %v", expected, profile, x, counts)
+			}
+		}
+	}
+
+	counts := CountProfile(BuildIndex("ac"), [][]byte{[]byte("a"), []byte("c"), []byte("g")})
+	if expected := [][]int{{0}, {0}, {0}}; !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected no matches for a profile longer than the text, got %v", counts)
+	}
+}
+
+func TestRangeCount(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(1+rng.Intn(300), "acgt", rng)
+		idx := BuildIndex(x)
+		for j := 0; j < 20; j++ {
+			p := randomStringN(rng.Intn(4), "acgt", rng)
+			lo := int32(rng.Intn(len(x)+2)) - 1
+			hi := lo + int32(rng.Intn(len(x)+2))
+
+			brute := 0
+			for k := lo; k < hi; k++ {
+				if k >= 0 && int(k)+len(p) <= len(x) && x[k:int(k)+len(p)] == p {
+					brute++
+				}
+			}
+			filtered := 0
+			for _, pos := range idx.Locate(p) {
+				if lo <= pos && pos < hi {
+					filtered++
+				}
+			}
+			count := RangeCount(idx, p, lo, hi)
+			if count != brute || count != filtered {
+				t.Errorf("RangeCount(%q, %d, %d) in %q = %d, expected %d (brute force) and %d (locate)",
+					p, lo, hi, x, count, brute, filtered)
+			}
+		}
+	}
+}
+
+func TestAllSuffixCounts(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(100, "acgt", rng)
+		idx := BuildIndex(x)
+		for j := 0; j < 10; j++ {
+			p := randomStringN(rng.Intn(8), "acgt", rng)
+			if j == 0 {
+				p = x[50:60] + "x" + x[10:15] // a restart in the middle
+			}
+			counts := AllSuffixCounts(idx, p)
+			if len(counts) != len(p) {
+				t.Errorf("Expected %d suffixes of %q, got %d", len(p), p, len(counts))
+			}
+			for k := range p {
+				if count, expected := counts[k], idx.Count(p[k:]); count != expected {
+					t.Errorf("Expected %d occurrences of %q, got %d", expected, p[k:], count)
+				}
+			}
+		}
+	}
+}
+
+func TestScoreOccurrences(t *testing.T) {
+	naive := func(x string, profile [][]float64, threshold float64) []ScoredMatch {
+		matches := []ScoredMatch{}
+	scan:
+		for pos := 0; pos+len(profile) <= len(x); pos++ {
+			score := 0.0
+			for i, scores := range profile {
+				c := int(x[pos+i])
+				if c >= len(scores) {
+					continue scan
+				}
+				score += scores[c]
+			}
+			if score >= threshold {
+				matches = append(matches, ScoredMatch{int32(pos), score})
+			}
+		}
+		return matches
+	}
+
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(300, "acgt", rng)
+		idx := BuildIndex(x)
+		profile := make([][]float64, 1+rng.Intn(5))
+		for j := range profile {
+			profile[j] = make([]float64, 256)
+			for _, c := range "acgt" {
+				profile[j][c] = float64(rng.Intn(7) - 3)
+			}
+		}
+		profile = append(profile, []float64{'a': 1, 'c': 2}) // only a and c allowed at the end
+		for _, threshold := range []float64{-100, 0, 3, 8} {
+			matches, expected := ScoreOccurrences(idx, profile, threshold), naive(x, profile, threshold)
+			if !reflect.DeepEqual(matches, expected) {
+				t.Errorf("Expected matches %v at threshold %v, got %v", expected, threshold, matches)
+			}
+		}
+	}
+}
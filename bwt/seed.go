@@ -0,0 +1,147 @@
+package bwt
+
+import "sort"
+
+// Match is an approximate occurrence of a pattern: the text x[Pos:End],
+// which is Edits edits from the pattern.
+type Match struct {
+	Pos, End int32
+	Edits    int
+}
+
+// alignWindow finds the best semi-global alignment of p within w, where
+// the alignment may start and end anywhere in w, and returns the span it
+// covers in w and its edit distance.
+func alignWindow[P pattern](p P, w string) (start, end, edits int) {
+	// cost[j] and from[j] are the edit distance and start in w of the best
+	// alignment of the current prefix of p that ends at w[:j].
+	cost := make([]int, len(w)+1)
+	from := make([]int, len(w)+1)
+	for j := range from {
+		from[j] = j
+	}
+	for i := 1; i <= len(p); i++ {
+		diagCost, diagFrom := cost[0], from[0]
+		cost[0], from[0] = i, 0
+		for j := 1; j <= len(w); j++ {
+			upCost, upFrom := cost[j], from[j]
+
+			best, bestFrom := diagCost, diagFrom
+			if p[i-1] != w[j-1] {
+				best++
+			}
+			if upCost+1 < best {
+				best, bestFrom = upCost+1, upFrom
+			}
+			if cost[j-1]+1 < best {
+				best, bestFrom = cost[j-1]+1, from[j-1]
+			}
+
+			diagCost, diagFrom = upCost, upFrom
+			cost[j], from[j] = best, bestFrom
+		}
+	}
+
+	end = 0
+	for j := 1; j <= len(w); j++ {
+		if cost[j] < cost[end] {
+			end = j
+		}
+	}
+	return from[end], end, cost[end]
+}
+
+// SeedAndExtend finds approximate occurrences of p with at most maxEdits
+// edits. It splits p into non-overlapping seeds of length seedLen, finds
+// the exact occurrences of each seed by backward search, and aligns p
+// against the text around each seed hit, within a band of maxEdits on
+// either side of where p would start. Matches that overlap in the text
+// are merged, keeping the one with the fewest edits, and the result is
+// sorted by position.
+//
+// If p is split into more than maxEdits seeds, every occurrence has a seed
+// that is unedited, so no occurrence is missed; with fewer seeds, the
+// search is a heuristic.
+func SeedAndExtend(index *Index, p string, seedLen, maxEdits int) []Match {
+	return seedAndExtend(index, p, seedLen, maxEdits)
+}
+
+// SeedAndExtendBytes is SeedAndExtend for a pattern given as a byte slice.
+func SeedAndExtendBytes(index *Index, p []byte, seedLen, maxEdits int) []Match {
+	return seedAndExtend(index, p, seedLen, maxEdits)
+}
+
+func seedAndExtend[P pattern](index *Index, p P, seedLen, maxEdits int) []Match {
+	matches := []Match{}
+	if seedLen <= 0 || seedLen > len(p) || len(p)-maxEdits > index.Len() {
+		return matches // no seeds, or every match is longer than the text
+	}
+	n := index.Len()
+
+	tried := map[int]bool{} // candidate start positions already aligned
+	for offset := 0; offset+seedLen <= len(p); offset += seedLen {
+		for _, hit := range index.positions(search(index, p[offset:offset+seedLen])) {
+			start := int(hit) - offset
+			if tried[start] {
+				continue
+			}
+			tried[start] = true
+
+			lo, hi := start-maxEdits, start+len(p)+maxEdits
+			if lo < 0 {
+				lo = 0
+			}
+			if hi > n {
+				hi = n
+			}
+			if from, to, edits := alignWindow(p, index.Extract(lo, hi)); edits <= maxEdits {
+				matches = append(matches, Match{int32(lo + from), int32(lo + to), edits})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Pos != matches[j].Pos {
+			return matches[i].Pos < matches[j].Pos
+		}
+		return matches[i].End < matches[j].End
+	})
+	merged := matches[:0]
+	for _, m := range matches {
+		if last := len(merged) - 1; last >= 0 && m.Pos < merged[last].End {
+			if m.Edits < merged[last].Edits {
+				merged[last] = m
+			}
+			continue
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// BestAnchor finds a seed of p that occurs exactly once in the text, to
+// anchor a chaining or alignment step: the offset patOffset of a length
+// seedLen substring of p, and the text position where it occurs. The
+// seeds are searched with backward search from the start of p, and the
+// first unique one is returned. It returns ok == false if no seed is
+// unique, or if seedLen is not in [1, len(p)].
+func BestAnchor(index *Index, p string, seedLen int) (patOffset int, textPos int32, ok bool) {
+	return bestAnchor(index, p, seedLen)
+}
+
+// BestAnchorBytes is BestAnchor for a pattern given as a byte slice.
+func BestAnchorBytes(index *Index, p []byte, seedLen int) (patOffset int, textPos int32, ok bool) {
+	return bestAnchor(index, p, seedLen)
+}
+
+func bestAnchor[P pattern](index *Index, p P, seedLen int) (patOffset int, textPos int32, ok bool) {
+	if seedLen < 1 || seedLen > len(p) || seedLen > index.Len() {
+		return 0, 0, false
+	}
+	for i := 0; i+seedLen <= len(p); i++ {
+		if L, R := search(index, p[i:i+seedLen]); R-L == 1 {
+			return i, index.position(L), true
+		}
+	}
+	return 0, 0, false
+}
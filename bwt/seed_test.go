@@ -0,0 +1,142 @@
+package bwt
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// editDistance is the Levenshtein distance between p and w.
+func editDistance(p, w string) int {
+	d := make([]int, len(w)+1)
+	for j := range d {
+		d[j] = j
+	}
+	for i := 1; i <= len(p); i++ {
+		diag := d[0]
+		d[0] = i
+		for j := 1; j <= len(w); j++ {
+			up := d[j]
+			best := diag
+			if p[i-1] != w[j-1] {
+				best++
+			}
+			if up+1 < best {
+				best = up + 1
+			}
+			if d[j-1]+1 < best {
+				best = d[j-1] + 1
+			}
+			diag, d[j] = up, best
+		}
+	}
+	return d[len(w)]
+}
+
+// mutate applies k random substitutions, insertions or deletions to p.
+func mutate(p string, k int, alpha string, rng *rand.Rand) string {
+	b := []byte(p)
+	for ; k > 0; k-- {
+		i := rng.Intn(len(b))
+		switch rng.Intn(3) {
+		case 0:
+			b[i] = alpha[rng.Intn(len(alpha))]
+		case 1:
+			b = append(b[:i], append([]byte{alpha[rng.Intn(len(alpha))]}, b[i:]...)...)
+		default:
+			b = append(b[:i], b[i+1:]...)
+		}
+	}
+	return string(b)
+}
+
+func TestSeedAndExtend(t *testing.T) {
+	rng := newRandomSeed(t)
+	const k, seedLen = 1, 4
+	for i := 0; i < 20; i++ {
+		p := randomStringN(12, "acgt", rng)
+		x, _ := randomStringWithMotifs(200, "acgt", []string{mutate(p, k, "acgt", rng), p}, rng)
+		idx := BuildIndex(x)
+		matches := SeedAndExtend(idx, p, seedLen, k)
+
+		for _, m := range matches {
+			if d := editDistance(p, x[m.Pos:m.End]); d != m.Edits || d > k {
+				t.Errorf("Match %v has %d edits", m, d)
+			}
+		}
+		for j := 1; j < len(matches); j++ {
+			if matches[j-1].End > matches[j].Pos {
+				t.Errorf("Matches %v and %v overlap", matches[j-1], matches[j])
+			}
+		}
+
+		// Every occurrence the brute-force aligner finds must overlap a
+		// reported match.
+		for s := 0; s < len(x); s++ {
+			for e := s + len(p) - k; e <= s+len(p)+k && e <= len(x); e++ {
+				if editDistance(p, x[s:e]) > k {
+					continue
+				}
+				found := false
+				for _, m := range matches {
+					if int(m.Pos) < e && s < int(m.End) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("Occurrence %q at [%d,%d) of %q was not found", x[s:e], s, e, p)
+				}
+			}
+		}
+	}
+
+	idx := BuildIndex("acgtacgt")
+	if matches := SeedAndExtend(idx, "acg", 4, 1); len(matches) != 0 {
+		t.Errorf("Expected no matches with seeds longer than the pattern, got %v", matches)
+	}
+}
+
+func TestBestAnchor(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(500, "acgt", rng)
+	idx := BuildIndex(x)
+	for i := 0; i < 50; i++ {
+		start := rng.Intn(len(x) - 100)
+		p := x[start : start+100]
+		seedLen := 3 + rng.Intn(10)
+		off, pos, ok := BestAnchor(idx, p, seedLen)
+		expectedOK := false
+		for j := 0; j+seedLen <= len(p); j++ {
+			if len(naiveOccurrences(x, p[j:j+seedLen])) == 1 {
+				expectedOK = true
+				if off != j {
+					t.Errorf("Expected the first unique seed at %d, got %d", j, off)
+				}
+				break
+			}
+		}
+		if ok != expectedOK {
+			t.Fatalf("Expected ok = %v for seed length %d, got %v", expectedOK, seedLen, ok)
+		}
+		if !ok {
+			continue
+		}
+		seed := p[off : off+seedLen]
+		if occ := naiveOccurrences(x, seed); len(occ) != 1 || occ[0] != pos {
+			t.Errorf("Expected seed %q to occur only at %d, got %v", seed, pos, occ)
+		}
+		if off2, pos2, _ := BestAnchorBytes(idx, []byte(p), seedLen); off2 != off || pos2 != pos {
+			t.Errorf("Expected BestAnchorBytes to agree, got (%d, %d)", off2, pos2)
+		}
+	}
+
+	idx = BuildIndex("aaaaaaaa")
+	if _, _, ok := BestAnchor(idx, "aaaa", 2); ok {
+		t.Errorf("Expected no unique anchor in a repeat")
+	}
+	if _, _, ok := BestAnchor(idx, "aaaa", 0); ok {
+		t.Errorf("Expected no anchor for an empty seed")
+	}
+	if _, _, ok := BestAnchor(idx, "aaaa", 5); ok {
+		t.Errorf("Expected no anchor for a seed longer than the pattern")
+	}
+}
@@ -0,0 +1,71 @@
+package bwt
+
+// NumRuns returns the number of runs of equal characters in y, such as a
+// BWT. Run-length encoding stores one entry per run, so the number of runs
+// measures how well the BWT compresses.
+func NumRuns(y string) int {
+	runs := 0
+	for i := 0; i < len(y); i++ {
+		if i == 0 || y[i] != y[i-1] {
+			runs++
+		}
+	}
+	return runs
+}
+
+// bwtRuns is the run-length compressed size of x's BWT, in runs.
+func bwtRuns(x string) int {
+	return NumRuns(Bwt(x))
+}
+
+// BwtSimilarity returns a similarity between 0 and 1 for a and b, based
+// on the normalized compression distance
+//
+//	NCD(a, b) = (C(ab) - min(C(a), C(b))) / max(C(a), C(b))
+//
+// with the number of runs in the BWT as the compressed size C. If b adds
+// little to a, the BWT of their concatenation has few more runs than the
+// BWT of a alone, and the distance is close to 0. The similarity is
+// 1 - NCD, clamped to [0, 1]; two empty strings are identical.
+func BwtSimilarity(a, b string) float64 {
+	ca, cb, cab := bwtRuns(a), bwtRuns(b), bwtRuns(a+b)
+	lo, hi := ca, cb
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+
+	// The sentinel is a run of its own in every BWT, so remove it to
+	// make the empty string compress to nothing.
+	lo, hi, cab = lo-1, hi-1, cab-1
+	if hi == 0 {
+		return 1
+	}
+
+	sim := 1 - float64(cab-lo)/float64(hi)
+	if sim < 0 {
+		return 0
+	}
+	if sim > 1 {
+		return 1
+	}
+	return sim
+}
+
+// WindowedRuns returns the number of runs in the BWT of each window of x,
+// the windows being x[i:i+window] for i = 0, step, 2*step, ... as long as
+// they fit in x. Repetitive, low-complexity regions have BWTs with few
+// runs, so the profile shows how repetitive the text is locally.
+//
+// Each window is transformed separately, so for a text of length n the
+// time is O(n/step * window log window). Overlapping windows repeat a lot
+// of work, so a step close to the window size is much faster.
+func WindowedRuns(x string, window, step int) []int {
+	runs := []int{}
+	if window <= 0 || step <= 0 {
+		return runs
+	}
+	for i := 0; i+window <= len(x); i += step {
+		runs = append(runs, bwtRuns(x[i:i+window]))
+	}
+	return runs
+}
@@ -0,0 +1,68 @@
+package bwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNumRuns(t *testing.T) {
+	for y, expected := range map[string]int{"": 0, "a": 1, "aaab": 2, "abab": 4, "aabbaa": 3} {
+		if runs := NumRuns(y); runs != expected {
+			t.Errorf("NumRuns(%q) = %d, expected %d", y, runs, expected)
+		}
+	}
+}
+
+func TestBwtSimilarity(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		a := randomStringN(500, "acgt", rng)
+		b := randomStringN(500, "acgt", rng)
+
+		same, unrelated := BwtSimilarity(a, a), BwtSimilarity(a, b)
+		if same < 0.9 {
+			t.Errorf("Expected a string to be very similar to itself, got %f", same)
+		}
+		if unrelated > 0.5 {
+			t.Errorf("Expected unrelated strings to be dissimilar, got %f", unrelated)
+		}
+		if same <= unrelated {
+			t.Errorf("Expected identical strings (%f) to be more similar than unrelated (%f)",
+				same, unrelated)
+		}
+	}
+
+	if sim := BwtSimilarity("", ""); sim != 1 {
+		t.Errorf("Expected empty strings to be identical, got %f", sim)
+	}
+}
+
+func TestWindowedRuns(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(200, "acgt", rng) + strings.Repeat("ac", 50)
+	for _, params := range [][2]int{{10, 1}, {20, 7}, {50, 50}, {300, 1}} {
+		window, step := params[0], params[1]
+		runs := WindowedRuns(x, window, step)
+		if expected := (len(x)-window)/step + 1; len(x) >= window && len(runs) != expected {
+			t.Errorf("Expected %d windows of size %d and step %d, got %d", expected, window, step, len(runs))
+		}
+		for k, r := range runs {
+			w := x[k*step : k*step+window]
+			if expected := NumRuns(Bwt(w)); r != expected {
+				t.Errorf("Expected %d runs in window %d, got %d", expected, k, r)
+			}
+		}
+	}
+	if runs := WindowedRuns(x, 300, 1); len(runs) != 1 {
+		t.Errorf("Expected a single window the size of the text, got %d", len(runs))
+	}
+
+	// The repetitive end of the text has fewer runs than the random start.
+	runs := WindowedRuns(x, 50, 50)
+	if runs[len(runs)-1] >= runs[0] {
+		t.Errorf("Expected fewer runs in the repeat (%d) than in random text (%d)", runs[len(runs)-1], runs[0])
+	}
+	if runs := WindowedRuns(x, 0, 1); len(runs) != 0 {
+		t.Errorf("Expected no windows of size 0, got %d", len(runs))
+	}
+}
@@ -0,0 +1,143 @@
+package bwt
+
+import (
+	"math"
+	"unsafe"
+)
+
+const (
+	intSize   = int(unsafe.Sizeof(int(0)))
+	int32Size = int(unsafe.Sizeof(int32(0)))
+)
+
+// IndexSizeEstimate holds the projected sizes, in bytes, of the structures
+// that make up an index. Only the payload of each structure is counted,
+// not the constant-size headers of slices and structs.
+type IndexSizeEstimate struct {
+	BWT  int // the BWT itself
+	CTab int // the C-table
+	OTab int // the full O-table
+	SA   int // the full 32-bit suffix array
+
+	// SampledOTab is the size of an O-table that only stores the ranks at
+	// every step'th position, a SparseOTab with that block size, keyed by
+	// the step.
+	SampledOTab map[int]int
+
+	// WaveletTree is the size of the wavelet matrix over the BWT that an
+	// Index uses for large alphabets: a Bitvector per level, with 64-bit
+	// words and a rank sample per word. The select directory depends on
+	// which bits are set, so it is counted at its largest, making the
+	// estimate an upper bound.
+	WaveletTree int
+}
+
+// sampledOTabSteps are the sample steps EstimateIndexSize reports.
+var sampledOTabSteps = []int{16, 32, 64, 128}
+
+// EstimateIndexSize estimates the size of the index structures for a text
+// of length textLen with sigma distinct characters, so the structures can
+// be chosen before anything is built. The sentinel adds one to both the
+// length and the alphabet.
+func EstimateIndexSize(textLen, sigma int) IndexSizeEstimate {
+	n, asize := textLen+1, sigma+1
+
+	est := IndexSizeEstimate{
+		BWT:         n,
+		CTab:        asize * intSize,
+		OTab:        (asize - 1) * n * intSize,
+		SA:          n * int32Size,
+		SampledOTab: map[int]int{},
+	}
+	for _, step := range sampledOTabSteps {
+		est.SampledOTab[step] = (asize - 1) * (n/step + 1) * intSize
+	}
+
+	levels := 1
+	for 1<<levels < asize {
+		levels++
+	}
+	words := (n + 63) / 64
+	est.WaveletTree = levels * (words*8 + (words+1)*intSize + selectSizeBound(n) + intSize)
+
+	return est
+}
+
+// SizeBytes returns the size of the C-table's payload in bytes.
+func (ctab *CTab) SizeBytes() int {
+	return len(ctab.cumsum) * intSize
+}
+
+// SizeBytes returns the size of the O-table's payload in bytes.
+func (otab *OTab) SizeBytes() int {
+	return len(otab.table) * intSize
+}
+
+// SizeBytes returns the size of the index's payload in bytes: the text,
+// the BWT, the suffix array or its samples, and the two tables.
+func (idx *Index) SizeBytes() int {
+	size := len(idx.text) + len(idx.bwt) + len(idx.sa)*int32Size + idx.ctab.SizeBytes() + idx.otab.SizeBytes()
+	if idx.samples != nil {
+		size += idx.samples.sizeBytes()
+	}
+	return size
+}
+
+// CompressedSizeEstimate estimates the number of bytes an entropy-coded
+// representation of the BWT would take: the smaller of what an order-k
+// entropy coder and a run-length coder would need.
+//
+// The order-k estimate is n*H_k, the sum over the contexts w of length k
+// of |B_w|*H_0(B_w), where B_w is the block of the BWT in the rows that
+// start with w. The blocks are the suffix-array intervals of the k-mers,
+// and the rows that are shorter than k are charged log2(sigma) bits each.
+// The order is k = floor(log_sigma n) - 1, beyond which most contexts
+// occur once and the estimate stops meaning anything. The run-length
+// estimate charges each of the r runs log2(sigma) bits for its character
+// and an Elias-gamma code of the average run length, 2*log2(n/r)+1 bits.
+func (idx *Index) CompressedSizeEstimate() int {
+	n, sigma := len(idx.bwt), idx.alpha.Size()
+	logSigma := math.Log2(float64(sigma))
+
+	k := 0
+	if sigma > 1 {
+		k = int(math.Log(float64(n))/math.Log(float64(sigma))) - 1
+	}
+	if k < 0 {
+		k = 0
+	}
+	entropyBits, covered := 0.0, 0
+	forEachKmer(idx, k, func(_ string, L, R int) {
+		covered += R - L
+		entropyBits += blockEntropyBits(idx.otab, sigma, L, R)
+	})
+	entropyBits += float64(n-covered) * logSigma
+
+	runs := NumRuns(string(idx.bwt))
+	runBits := float64(runs) * (logSigma + 2*math.Log2(float64(n)/float64(runs)) + 1)
+
+	bits := entropyBits
+	if runBits < bits {
+		bits = runBits
+	}
+	return int(math.Ceil(bits / 8))
+}
+
+// blockEntropyBits returns |B|*H_0(B) for the block B = bwt[L:R], with
+// the counts of its symbols taken from the O-table.
+func blockEntropyBits(otab occTable, sigma, L, R int) float64 {
+	bits, total := 0.0, float64(R-L)
+	rest := R - L // the sentinel is whatever the other symbols leave
+	count := func(c int) {
+		if c > 0 {
+			bits -= float64(c) * math.Log2(float64(c)/total)
+		}
+	}
+	for a := 1; a < sigma; a++ {
+		c := otab.RangeRank(byte(a), L, R)
+		rest -= c
+		count(c)
+	}
+	count(rest)
+	return bits
+}
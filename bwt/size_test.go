@@ -0,0 +1,67 @@
+package bwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateIndexSize(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, alpha := range []string{"a", "acgt", "abcdefghij"} {
+		x := randomStringN(100+rng.Intn(100), alpha, rng)
+		idx := BuildIndex(x)
+		est := EstimateIndexSize(len(x), idx.Alphabet().Size()-1)
+
+		if est.BWT != len(idx.bwt) {
+			t.Errorf("BWT: estimated %d, actual %d", est.BWT, len(idx.bwt))
+		}
+		if est.CTab != idx.ctab.SizeBytes() {
+			t.Errorf("CTab: estimated %d, actual %d", est.CTab, idx.ctab.SizeBytes())
+		}
+		if est.OTab != idx.otab.SizeBytes() {
+			t.Errorf("OTab: estimated %d, actual %d", est.OTab, idx.otab.SizeBytes())
+		}
+		if est.SA != len(idx.sa)*4 {
+			t.Errorf("SA: estimated %d, actual %d", est.SA, len(idx.sa)*4)
+		}
+		if total := len(x) + est.BWT + est.CTab + est.OTab + est.SA; total != idx.SizeBytes() {
+			t.Errorf("Index: estimated %d, actual %d", total, idx.SizeBytes())
+		}
+
+		for step, size := range est.SampledOTab {
+			if actual := NewSparseOTab(idx.bwt, idx.Alphabet().Size(), step).SizeBytes(); size != actual {
+				t.Errorf("Sampled O-table with step %d: estimated %d, actual %d", step, size, actual)
+			}
+		}
+
+		// The select directory is the only part that depends on the bits,
+		// and the estimate counts it at its largest.
+		wm := newWaveletOTab(idx.bwt).wm
+		slack := len(wm.levels) * selectSizeBound(len(idx.bwt))
+		if est.WaveletTree < wm.sizeBytes() || est.WaveletTree > wm.sizeBytes()+slack {
+			t.Errorf("WaveletTree: estimated %d, actual %d", est.WaveletTree, wm.sizeBytes())
+		}
+	}
+}
+
+func TestCompressedSizeEstimate(t *testing.T) {
+	rng := newRandomSeed(t)
+	n := 10000
+	random := randomStringN(n, "acgt", rng)
+	repetitive := strings.Repeat(randomStringN(100, "acgt", rng), n/100)
+
+	r, p := BuildIndex(random).CompressedSizeEstimate(), BuildIndex(repetitive).CompressedSizeEstimate()
+	if p >= r {
+		t.Errorf("Expected the repetitive text (%d bytes) to compress better than the random (%d bytes)", p, r)
+	}
+	// Random DNA needs about two bits per character.
+	if r < n/5 || r > n/3 {
+		t.Errorf("Expected about %d bytes for random DNA, got %d", n/4, r)
+	}
+	if size := BuildIndex("aaaa").CompressedSizeEstimate(); size < 0 || size > 4 {
+		t.Errorf("Expected a few bytes for aaaa, got %d", size)
+	}
+	if size := BuildIndex("").CompressedSizeEstimate(); size != 0 {
+		t.Errorf("Expected nothing for the empty text, got %d", size)
+	}
+}
@@ -0,0 +1,132 @@
+package bwt
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+)
+
+// BuildStats records where the time and memory went while an index was
+// built by BuildIndexStats.
+type BuildStats struct {
+	// The wall time of each phase of the construction. The SA phase
+	// includes computing the alphabet.
+	SA, BWT, CTab, OTab time.Duration
+
+	// TotalAlloc is the number of bytes allocated during the build, and
+	// PeakHeap the largest live heap seen at the end of a phase. Both are
+	// read from the runtime's memory statistics, so they include any
+	// allocations other goroutines make meanwhile.
+	TotalAlloc, PeakHeap uint64
+
+	// The sizes, in bytes, of the structures in the finished index, as
+	// reported by their SizeBytes methods.
+	SABytes, BWTBytes, CTabBytes, OTabBytes int
+}
+
+// BuildIndexStats builds the FM-index for x like BuildIndex and reports
+// the time and memory each phase of the construction used. Reading the
+// memory statistics stops the world, so the build is a little slower
+// than with BuildIndex.
+func BuildIndexStats(x string) (*Index, BuildStats) {
+	var stats BuildStats
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	startAlloc := mem.TotalAlloc
+	start := time.Now()
+	phase := func(d *time.Duration) {
+		now := time.Now()
+		*d = now.Sub(start)
+		runtime.ReadMemStats(&mem)
+		if mem.HeapAlloc > stats.PeakHeap {
+			stats.PeakHeap = mem.HeapAlloc
+		}
+		start = time.Now() // so reading the statistics is not counted
+	}
+
+	alpha := NewAlphabet(x)
+	y, _ := alpha.MapString(x) // cannot fail; alpha has all of x's characters
+	sa := PrefixDoubling(x)
+	phase(&stats.SA)
+	bwt := bwtFromSA(string(y), sa)
+	phase(&stats.BWT)
+	ctab := NewCTab(bwt, alpha.Size())
+	phase(&stats.CTab)
+	otab := newOccTable(bwt, alpha.Size())
+	phase(&stats.OTab)
+	stats.TotalAlloc = mem.TotalAlloc - startAlloc
+
+	idx := &Index{text: x, sa: sa, bwt: bwt, alpha: alpha, ctab: ctab, otab: otab}
+	stats.SABytes = len(sa) * int32Size
+	stats.BWTBytes = len(bwt)
+	stats.CTabBytes = ctab.SizeBytes()
+	stats.OTabBytes = otab.SizeBytes()
+	return idx, stats
+}
+
+// saConstructors are the suffix array constructors RecommendSAConstructor
+// chooses between.
+var saConstructors = []string{"SAIS", "PrefixDoublingWidth[int64]"}
+
+// SAConstructors returns the names of the suffix array constructors that
+// RecommendSAConstructor can recommend.
+func SAConstructors() []string {
+	return append([]string{}, saConstructors...)
+}
+
+// doublingRoundNanos is the measured time of one prefix-doubling round per
+// character, on texts of a million characters, where most of it is cache
+// misses in the radix sort.
+const doublingRoundNanos = 200
+
+// saisNanos and saisBytes are the measured time and allocation of SAIS
+// per character, on texts of a million characters, random or repetitive.
+const (
+	saisNanos = 120
+	saisBytes = 30
+)
+
+// RecommendSAConstructor recommends a suffix array constructor for a text
+// of length textLen over sigma distinct characters, and explains why with
+// its estimated memory and time.
+//
+// SAIS runs in linear time, whatever the text, so it is recommended
+// unless the text is too long for its 32-bit positions, even though it
+// allocates about two and a half times the memory of prefix doubling.
+// For longer texts, the estimates are for the 64-bit variant of prefix
+// doubling, which keeps the suffix array, the ranks and a buffer of the
+// same size, and whose rounds double the sorted prefix length until all
+// suffixes are distinct: about log2(log_sigma(n))+1 rounds for random
+// text, and log2(n) for repetitive text, where the longest repeat is a
+// fraction of n.
+func RecommendSAConstructor(textLen int, sigma int, repetitive bool) (name, rationale string) {
+	n := float64(textLen + 1)
+	if textLen < math.MaxInt32 {
+		elapsed := time.Duration(saisNanos * n)
+		rationale = fmt.Sprintf("SAIS needs about %d MB (%d bytes per character) and linear time of about %d ns per character, about %v",
+			saisBytes*(textLen+1)>>20, saisBytes, saisNanos, elapsed.Round(time.Millisecond))
+		return "SAIS", rationale
+	}
+
+	if sigma < 2 {
+		sigma = 2
+	}
+	rounds := 1
+	switch {
+	case repetitive:
+		rounds = int(math.Ceil(math.Log2(n)))
+	case n > float64(sigma):
+		rounds = int(math.Ceil(math.Log2(math.Log(n)/math.Log(float64(sigma))))) + 1
+	}
+	if rounds < 1 {
+		rounds = 1
+	}
+
+	name, width := "PrefixDoublingWidth[int64]", 8
+	memory := 3 * width * (textLen + 1)
+	elapsed := time.Duration(float64(rounds) * doublingRoundNanos * n)
+	rationale = fmt.Sprintf("%s needs about %d MB (%d bytes per character) and %d rounds of about %d ns per character, about %v",
+		name, memory>>20, 3*width, rounds, doublingRoundNanos, elapsed.Round(time.Millisecond))
+	return name, rationale
+}
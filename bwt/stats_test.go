@@ -0,0 +1,71 @@
+package bwt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildIndexStats(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(10000, "acgt", rng)
+	idx, stats := BuildIndexStats(x)
+
+	expected := BuildIndex(x)
+	if !reflect.DeepEqual(idx.bwt, expected.bwt) || !reflect.DeepEqual(idx.sa, expected.sa) {
+		t.Error("Expected the same index as BuildIndex")
+	}
+
+	for name, d := range map[string]time.Duration{
+		"SA": stats.SA, "BWT": stats.BWT, "CTab": stats.CTab, "OTab": stats.OTab,
+	} {
+		if d < 0 {
+			t.Errorf("Expected a non-negative time for the %s phase, got %v", name, d)
+		}
+	}
+	if stats.SA+stats.BWT+stats.CTab+stats.OTab <= 0 {
+		t.Error("Expected the phases to take some time")
+	}
+	if stats.TotalAlloc == 0 || stats.PeakHeap == 0 {
+		t.Errorf("Expected allocations to be recorded, got %d total and %d peak", stats.TotalAlloc, stats.PeakHeap)
+	}
+	if uint64(stats.OTabBytes) > stats.TotalAlloc {
+		t.Errorf("Expected at least the O-table's %d bytes to be allocated, got %d", stats.OTabBytes, stats.TotalAlloc)
+	}
+	sizes := stats.SABytes + stats.BWTBytes + stats.CTabBytes + stats.OTabBytes
+	if sizes != idx.SizeBytes()-len(x) {
+		t.Errorf("Expected the sizes to add up to %d bytes, got %d", idx.SizeBytes()-len(x), sizes)
+	}
+}
+
+func TestRecommendSAConstructor(t *testing.T) {
+	registered, recommended := map[string]bool{}, map[string]bool{}
+	for _, name := range SAConstructors() {
+		registered[name] = true
+	}
+	for _, n := range []int{0, 1, 100, 1 << 20, 1 << 31} {
+		for _, sigma := range []int{1, 4, 20, 255} {
+			for _, repetitive := range []bool{false, true} {
+				name, rationale := RecommendSAConstructor(n, sigma, repetitive)
+				if !registered[name] {
+					t.Errorf("Expected a registered constructor for n=%d, sigma=%d, got %q", n, sigma, name)
+				}
+				recommended[name] = true
+				if rationale == "" {
+					t.Errorf("Expected a rationale for n=%d, sigma=%d", n, sigma)
+				}
+			}
+		}
+	}
+	for name := range registered {
+		if !recommended[name] {
+			t.Errorf("Expected %q to be recommended for some text", name)
+		}
+	}
+	if name, _ := RecommendSAConstructor(1<<31, 4, false); name != "PrefixDoublingWidth[int64]" {
+		t.Errorf("Expected 64-bit positions for a text of 2^31 characters, got %q", name)
+	}
+	if name, _ := RecommendSAConstructor(1<<20, 4, true); name != "SAIS" {
+		t.Errorf("Expected SAIS for a repetitive text of 2^20 characters, got %q", name)
+	}
+}
@@ -0,0 +1,223 @@
+package bwt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// bwtMagic identifies a BWT written by SaveBwt, and bwtMappedMagic one
+// whose alphabet maps bytes other than its letters, as with
+// BuildIndexMasked.
+const (
+	bwtMagic       = "BWT1"
+	bwtMappedMagic = "BWT2"
+)
+
+// SaveBwt writes a BWT over the alphabet alpha to w, as returned by the
+// index's BWT and Alphabet methods. Only the BWT and the alphabet are
+// stored; the C- and O-tables are cheap to rebuild with NewCTab and NewOTab
+// after loading, and the suffix array is not needed to search or reverse
+// the transform.
+//
+// The format is the magic string "BWT1", the number of letters in the
+// alphabet (excluding the sentinel) as one byte, the letters in order, the
+// length of the BWT as a little-endian uint64, and then the BWT symbols.
+// An alphabet that maps other bytes than its letters, such as the folded
+// or excluded lowercase letters of BuildIndexMasked, is stored with the
+// magic string "BWT2" and the symbol of each of the 256 bytes after the
+// letters, so patterns map the same way after loading.
+func SaveBwt(w io.Writer, bwt []byte, alpha *Alphabet) error {
+	bw := bufio.NewWriter(w)
+	mapped := alphabetFromLetters(alpha.letters).symbols != alpha.symbols
+	magic := bwtMagic
+	if mapped {
+		magic = bwtMappedMagic
+	}
+	header := append([]byte(magic), byte(alpha.Size()-1))
+	header = append(header, alpha.letters[1:]...)
+	if mapped {
+		header = append(header, alpha.symbols[:]...)
+	}
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, uint64(len(bwt))); err != nil {
+		return err
+	}
+	if _, err := bw.Write(bwt); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// LoadBwt reads a BWT and its alphabet written by SaveBwt. The BWT is read
+// as it arrives rather than allocated from the stored length, so a
+// corrupt length fails when the data runs out, and a BWT without exactly
+// one sentinel, or with symbols outside the alphabet, is rejected.
+func LoadBwt(r io.Reader) (bwt []byte, alpha *Alphabet, err error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(bwtMagic)+1)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, nil, err
+	}
+	mapped := string(magic[:len(bwtMagic)]) == bwtMappedMagic
+	if !mapped && string(magic[:len(bwtMagic)]) != bwtMagic {
+		return nil, nil, fmt.Errorf("not a BWT file")
+	}
+
+	nletters := int(magic[len(bwtMagic)])
+	letters := make([]byte, nletters+1)
+	if _, err := io.ReadFull(br, letters[1:]); err != nil {
+		return nil, nil, err
+	}
+	if nletters > 0 && letters[1] == 0 {
+		return nil, nil, fmt.Errorf("the zero byte is the sentinel, not a letter")
+	}
+	for i := 2; i < len(letters); i++ {
+		if letters[i-1] >= letters[i] {
+			return nil, nil, fmt.Errorf("alphabet letters are not sorted")
+		}
+	}
+	alpha = alphabetFromLetters(letters)
+	if mapped {
+		if _, err := io.ReadFull(br, alpha.symbols[:]); err != nil {
+			return nil, nil, err
+		}
+		for a, sym := range alpha.symbols {
+			if int(sym) >= alpha.Size() {
+				return nil, nil, fmt.Errorf("byte %q maps to symbol %d outside the alphabet", a, sym)
+			}
+		}
+		for sym := 1; sym < len(letters); sym++ {
+			if s := alpha.symbols[letters[sym]]; s != 0 && int(s) != sym {
+				return nil, nil, fmt.Errorf("letter %q maps to symbol %d instead of its own", letters[sym], s)
+			}
+		}
+	}
+
+	var n uint64
+	if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+		return nil, nil, err
+	}
+	if n > math.MaxInt64 {
+		return nil, nil, fmt.Errorf("invalid BWT length %d", n)
+	}
+	bwt, err = io.ReadAll(io.LimitReader(br, int64(n)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if uint64(len(bwt)) < n {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	sentinels := 0
+	for i, s := range bwt {
+		if int(s) >= alpha.Size() {
+			return nil, nil, fmt.Errorf("symbol %d at index %d is not in the alphabet", s, i)
+		}
+		if s == 0 {
+			sentinels++
+		}
+	}
+	if sentinels != 1 {
+		return nil, nil, fmt.Errorf("a BWT must contain exactly one sentinel, found %d", sentinels)
+	}
+	return bwt, alpha, nil
+}
+
+// SABits returns the number of bits PackSA needs per entry for the suffix
+// array of a text of length n: ceil(log2(n+1)), and at least 1, since the
+// entries are the positions 0 through n.
+func SABits(n int) int {
+	bits := 1
+	for 1<<bits < n+1 {
+		bits++
+	}
+	return bits
+}
+
+// PackSA packs the entries of sa into bitsPerEntry bits each, least
+// significant bit first, for compact storage or transfer. With
+// bitsPerEntry = SABits(len(sa)-1), the packing takes len(sa)*bits/8
+// bytes instead of 4*len(sa). It panics if bitsPerEntry is not in [1, 32]
+// or an entry does not fit.
+func PackSA(sa []int32, bitsPerEntry int) []byte {
+	if bitsPerEntry < 1 || bitsPerEntry > 32 {
+		panic("bwt: bits per entry out of range")
+	}
+	packed := make([]byte, (len(sa)*bitsPerEntry+7)/8)
+	bit := 0
+	for _, v := range sa {
+		if v < 0 || bitsPerEntry < 32 && int64(v)>>bitsPerEntry != 0 {
+			panic("bwt: suffix array entry does not fit")
+		}
+		for k := 0; k < bitsPerEntry; k, bit = k+1, bit+1 {
+			if v>>k&1 == 1 {
+				packed[bit/8] |= 1 << (bit % 8)
+			}
+		}
+	}
+	return packed
+}
+
+// UnpackSA unpacks n entries of bitsPerEntry bits each from packed, as
+// written by PackSA. It panics if bitsPerEntry is not in [1, 32] or packed
+// is too short.
+func UnpackSA(packed []byte, n, bitsPerEntry int) []int32 {
+	if bitsPerEntry < 1 || bitsPerEntry > 32 {
+		panic("bwt: bits per entry out of range")
+	}
+	if len(packed)*8 < n*bitsPerEntry {
+		panic("bwt: packed suffix array is too short")
+	}
+	sa := make([]int32, n)
+	bit := 0
+	for i := range sa {
+		v := uint32(0)
+		for k := 0; k < bitsPerEntry; k, bit = k+1, bit+1 {
+			v |= uint32(packed[bit/8]>>(bit%8)&1) << k
+		}
+		sa[i] = int32(v)
+	}
+	return sa
+}
+
+// DeltaEncodeSA encodes the suffix array sa relative to the suffix array
+// ref of a similar text of the same length. Entry i of the delta is how
+// far the suffix in row i of sa sits from its row in ref: if suffix j is
+// in row i of sa and row i' of ref, delta[i] is i' - i. Texts that differ
+// by a few substitutions only move the few suffixes that read through a
+// changed character, and every other suffix shifts by at most the number
+// of moved suffixes that cross it, so the delta is mostly small values
+// that compress well, even though the entries of sa themselves change in
+// long stretches. DeltaEncodeSA panics if the arrays differ in length.
+func DeltaEncodeSA(ref, sa []int32) []int32 {
+	if len(ref) != len(sa) {
+		panic("bwt: delta encoding needs suffix arrays of the same length")
+	}
+	isa := make([]int32, len(ref))
+	for i, j := range ref {
+		isa[j] = int32(i)
+	}
+	delta := make([]int32, len(sa))
+	for i, j := range sa {
+		delta[i] = isa[j] - int32(i)
+	}
+	return delta
+}
+
+// DeltaDecodeSA returns the suffix array that DeltaEncodeSA encoded as
+// delta relative to ref.
+func DeltaDecodeSA(ref, delta []int32) []int32 {
+	if len(ref) != len(delta) {
+		panic("bwt: delta decoding needs a reference of the same length")
+	}
+	sa := make([]int32, len(delta))
+	for i, d := range delta {
+		sa[i] = ref[int32(i)+d]
+	}
+	return sa
+}
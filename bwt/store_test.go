@@ -0,0 +1,143 @@
+package bwt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSaveLoadBwt(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, x := range []string{"", "mississippi", randomStringN(100, "acgt", rng)} {
+		idx := BuildIndex(x)
+
+		var buf bytes.Buffer
+		if err := SaveBwt(&buf, idx.BWT(), idx.Alphabet()); err != nil {
+			t.Fatal(err)
+		}
+		bwt, alpha, err := LoadBwt(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(bwt, idx.BWT()) {
+			t.Errorf("Expected BWT %v, got %v", idx.BWT(), bwt)
+		}
+		if alpha.Size() != idx.Alphabet().Size() {
+			t.Errorf("Expected alphabet size %d, got %d", idx.Alphabet().Size(), alpha.Size())
+		}
+		if y, _ := alpha.Unmap(reverseBwt(bwt, alpha.Size())); y != x {
+			t.Errorf("Expected the loaded BWT to reverse to %q, got %q", x, y)
+		}
+	}
+
+	if _, _, err := LoadBwt(bytes.NewBufferString("nope")); err == nil {
+		t.Errorf("Expected an error for a file that isn't a BWT")
+	}
+
+	var buf bytes.Buffer
+	idx := BuildIndex("mississippi")
+	if err := SaveBwt(&buf, idx.BWT(), idx.Alphabet()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	corrupt := func(offset int, b byte) []byte {
+		c := append([]byte{}, data...)
+		c[offset] = b
+		return c
+	}
+	header := len(bwtMagic) + 1 + idx.Alphabet().Size() - 1
+	for name, input := range map[string][]byte{
+		"length":    corrupt(header+3, 0x10), // far more symbols than data
+		"huge":      corrupt(header+7, 0xff), // over 63 bits
+		"truncated": data[:len(data)-1],
+		"sentinel":  corrupt(len(data)-1, 0), // a second sentinel
+		"symbol":    corrupt(len(data)-1, 9),
+		"letter":    corrupt(len(bwtMagic)+1, 0),
+	} {
+		if _, _, err := LoadBwt(bytes.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for a corrupt %s", name)
+		}
+	}
+}
+
+func TestSaveLoadBwtMasked(t *testing.T) {
+	for _, mode := range []MaskMode{MaskFold, MaskExclude} {
+		idx := BuildIndexMasked("ACGTacgtAC", mode)
+		var buf bytes.Buffer
+		if err := SaveBwt(&buf, idx.BWT(), idx.Alphabet()); err != nil {
+			t.Fatal(err)
+		}
+		bwt, alpha, err := LoadBwt(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(bwt, idx.BWT()) || !reflect.DeepEqual(alpha, idx.Alphabet()) {
+			t.Errorf("Expected the masked alphabet to survive saving, got %v", alpha)
+		}
+	}
+}
+
+func TestPackSA(t *testing.T) {
+	for _, test := range []struct{ n, bits int }{{0, 1}, {1, 1}, {2, 2}, {3, 2}, {4, 3}, {255, 8}, {256, 9}, {1 << 20, 21}} {
+		if bits := SABits(test.n); bits != test.bits {
+			t.Errorf("Expected %d bits for a text of length %d, got %d", test.bits, test.n, bits)
+		}
+	}
+
+	rng := newRandomSeed(t)
+	// Lengths around powers of two, where the number of bits changes.
+	for _, n := range []int{0, 1, 7, 8, 15, 16, 255, 256, 1023, 1024} {
+		x := randomStringN(n, "acgt", rng)
+		sa := PrefixDoubling(x)
+		bits := SABits(n)
+		packed := PackSA(sa, bits)
+		if expected := (len(sa)*bits + 7) / 8; len(packed) != expected {
+			t.Errorf("Expected %d packed bytes for n = %d, got %d", expected, n, len(packed))
+		}
+		if unpacked := UnpackSA(packed, len(sa), bits); !reflect.DeepEqual(unpacked, sa) {
+			t.Errorf("Expected the suffix array of length %d back, got %v", n, unpacked)
+		}
+	}
+
+	values := []int32{0, 1, 1<<31 - 1, 12345}
+	if unpacked := UnpackSA(PackSA(values, 32), len(values), 32); !reflect.DeepEqual(unpacked, values) {
+		t.Errorf("Expected %v back with 32 bits, got %v", values, unpacked)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for an entry that does not fit")
+		}
+	}()
+	PackSA([]int32{4}, 2)
+}
+
+func TestDeltaEncodeSA(t *testing.T) {
+	rng := newRandomSeed(t)
+	for _, n := range []int{0, 1, 10, 2000} {
+		x := randomStringN(n, "acgt", rng)
+		y := []byte(x)
+		for i := 0; i < 3 && n > 0; i++ {
+			y[rng.Intn(n)] = "acgt"[rng.Intn(4)]
+		}
+		ref, sa := PrefixDoubling(x), PrefixDoubling(string(y))
+
+		delta := DeltaEncodeSA(ref, sa)
+		if decoded := DeltaDecodeSA(ref, delta); !reflect.DeepEqual(decoded, sa) {
+			t.Fatalf("Expected the suffix array back for n = %d, got %v", n, decoded)
+		}
+		if n < 2000 {
+			continue
+		}
+		small := 0
+		for _, d := range delta {
+			if -8 <= d && d <= 8 {
+				small++
+			}
+		}
+		if small < len(delta)*9/10 {
+			t.Errorf("Expected at least 90%% small deltas for three substitutions, got %d of %d", small, len(delta))
+		}
+	}
+}
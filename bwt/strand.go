@@ -0,0 +1,80 @@
+package bwt
+
+// strandSeparator separates the forward and reverse-complement strands in
+// a double-strand index. It is not a nucleotide, so no read matches
+// across it.
+const strandSeparator = '#'
+
+// complement maps each nucleotide to its Watson-Crick complement,
+// preserving case, and every other byte to itself.
+var complement = func() (table [256]byte) {
+	for i := range table {
+		table[i] = byte(i)
+	}
+	for _, pair := range []string{"AT", "TA", "CG", "GC", "at", "ta", "cg", "gc"} {
+		table[pair[0]] = pair[1]
+	}
+	return table
+}()
+
+// ReverseComplement returns the reverse complement of the DNA string x.
+// Bytes other than a, c, g and t, in either case, are kept as they are.
+func ReverseComplement(x string) string {
+	y := make([]byte, len(x))
+	for i := 0; i < len(x); i++ {
+		y[len(x)-1-i] = complement[x[i]]
+	}
+	return string(y)
+}
+
+// BuildDoubleStrandIndex builds the FM-index for both strands of the DNA
+// string x, by indexing x + "#" + ReverseComplement(x). Use
+// LocateDoubleStrand to map its hits back to coordinates in x.
+func BuildDoubleStrandIndex(x string) *Index {
+	idx := BuildIndex(x + string(strandSeparator) + ReverseComplement(x))
+	idx.doubleStrand = true
+	return idx
+}
+
+// StrandHit is an occurrence of a pattern in a double-strand index. Pos
+// is the start of the occurrence in the forward strand, and Reverse says
+// if the pattern matched the reverse strand, so it is the reverse
+// complement of the pattern that occurs at Pos in the forward strand.
+type StrandHit struct {
+	Pos     int32
+	Reverse bool
+}
+
+// LocateDoubleStrand returns the occurrences of p on both strands of an
+// index built with BuildDoubleStrandIndex, in suffix-array order. Matches
+// that span the separator between the strands are not reported. It panics
+// if the index is not a double-strand index.
+func LocateDoubleStrand(index *Index, p string) []StrandHit {
+	return locateDoubleStrand(index, p)
+}
+
+// LocateDoubleStrandBytes is LocateDoubleStrand for a pattern given as a
+// byte slice.
+func LocateDoubleStrandBytes(index *Index, p []byte) []StrandHit {
+	return locateDoubleStrand(index, p)
+}
+
+func locateDoubleStrand[P pattern](index *Index, p P) []StrandHit {
+	if !index.doubleStrand {
+		panic("bwt: not a double-strand index")
+	}
+	n, m := int32(index.Len()/2), int32(len(p))
+	hits := []StrandHit{}
+	for _, pos := range index.positions(search(index, p)) {
+		switch {
+		case pos+m <= n:
+			hits = append(hits, StrandHit{Pos: pos})
+		case pos > n:
+			// The match starts at r = pos-n-1 in the reverse
+			// complement, and its m characters there are the reverse
+			// complement of x[n-r-m:n-r].
+			hits = append(hits, StrandHit{Pos: n - (pos - n - 1) - m, Reverse: true})
+		}
+	}
+	return hits
+}
@@ -0,0 +1,85 @@
+package bwt
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestReverseComplement(t *testing.T) {
+	if y := ReverseComplement("AACGTnacg"); y != "cgtnACGTT" {
+		t.Errorf("Expected cgtnACGTT, got %q", y)
+	}
+}
+
+func TestLocateDoubleStrand(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		x := randomStringN(100, "acgt", rng)
+		idx := BuildDoubleStrandIndex(x)
+		for j := 0; j < 10; j++ {
+			k := 1 + rng.Intn(6)
+			start := rng.Intn(len(x) - k + 1)
+			kmer := x[start : start+k]
+
+			// The forward k-mer and its reverse complement must both
+			// map to start, on the forward and reverse strand.
+			hits := LocateDoubleStrand(idx, kmer)
+			if !containsHit(hits, StrandHit{Pos: int32(start)}) {
+				t.Errorf("Expected %q at %d on the forward strand, got %v", kmer, start, hits)
+			}
+			rc := ReverseComplement(kmer)
+			hits = LocateDoubleStrand(idx, rc)
+			if !containsHit(hits, StrandHit{Pos: int32(start), Reverse: true}) {
+				t.Errorf("Expected %q at %d on the reverse strand, got %v", rc, start, hits)
+			}
+
+			// And all hits must be the brute-force ones.
+			expected := []StrandHit{}
+			for _, pos := range naiveOccurrences(x, rc) {
+				expected = append(expected, StrandHit{Pos: pos})
+			}
+			for _, pos := range naiveOccurrences(x, kmer) {
+				expected = append(expected, StrandHit{Pos: pos, Reverse: true})
+			}
+			sortHits(hits)
+			sortHits(expected)
+			if !reflect.DeepEqual(hits, expected) {
+				t.Errorf("Expected hits %v for %q, got %v", expected, rc, hits)
+			}
+		}
+	}
+
+	// Patterns spanning the separator are not hits.
+	idx := BuildDoubleStrandIndex("acgg")
+	if hits := LocateDoubleStrand(idx, "g#c"); len(hits) != 0 {
+		t.Errorf("Expected no hits across the separator, got %v", hits)
+	}
+}
+
+func TestLocateDoubleStrandPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a single-strand index")
+		}
+	}()
+	LocateDoubleStrand(BuildIndex("acgt"), "a")
+}
+
+func containsHit(hits []StrandHit, hit StrandHit) bool {
+	for _, h := range hits {
+		if h == hit {
+			return true
+		}
+	}
+	return false
+}
+
+func sortHits(hits []StrandHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Reverse != hits[j].Reverse {
+			return !hits[i].Reverse
+		}
+		return hits[i].Pos < hits[j].Pos
+	})
+}
@@ -0,0 +1,174 @@
+package bwt
+
+import "fmt"
+
+// SubstituteChar returns the index of the text with x[textPos] replaced by
+// newChar, updating the BWT, the suffix array and the tables of index
+// instead of rebuilding them. index itself is not modified.
+//
+// The update follows the dynamic BWT of Salson et al.: the BWT entry for
+// x[textPos] is changed, which can move the suffix at textPos to a new
+// row. The row is deleted and inserted at the position LF-mapping now
+// gives it, and the same is repeated for the suffixes before it until a
+// suffix is already in its place. This saves sorting the suffixes again,
+// but not linear work: the update copies the BWT, the suffix array and
+// the O-table, O(sigma*n), rebuilds the inverse suffix array, O(n),
+// changes the O-table entries of the old and new symbol from the changed
+// row to the end, O(n), and each row that moves costs sigma times the
+// number of rows it passes.
+//
+// On a double-strand index, textPos is a position in the forward strand,
+// and the mirrored position in the reverse strand gets the complement of
+// newChar, so both strands stay reverse complements of each other.
+//
+// The alphabet is kept, so newChar must already occur in the text, and
+// the index must hold its full suffix array, not the samples of a compact
+// index, and an OTab, not the wavelet matrix of a large alphabet.
+func SubstituteChar(index *Index, textPos int, newChar byte) (*Index, error) {
+	n := index.Len()
+	if index.doubleStrand {
+		n = (index.Len() - 1) / 2 // the forward strand, before the separator
+	}
+	if textPos < 0 || textPos >= n {
+		return nil, fmt.Errorf("position %d is outside the text of length %d", textPos, n)
+	}
+	if index.samples != nil {
+		return nil, fmt.Errorf("a compact index cannot be updated")
+	}
+	if _, ok := index.otab.(*OTab); !ok {
+		return nil, fmt.Errorf("an index over a large alphabet cannot be updated")
+	}
+	newSym, ok := index.alpha.Map(newChar)
+	if !ok || (index.doubleStrand && newChar == strandSeparator) {
+		return nil, fmt.Errorf("character %q is not in the alphabet", newChar)
+	}
+	if !index.doubleStrand {
+		return substitute(index, textPos, newSym), nil
+	}
+
+	// Every character of a double-strand text has its complement in the
+	// other strand, so the complement is in the alphabet too.
+	compSym, _ := index.alpha.Map(complement[newChar])
+	forward := substitute(index, textPos, newSym)
+	return substitute(forward, index.Len()-1-textPos, compSym), nil
+}
+
+// substitute is SubstituteChar on the whole text, for a valid position
+// and symbol.
+func substitute(index *Index, textPos int, newSym byte) *Index {
+	otab := index.otab.(*OTab)
+	text := []byte(index.text)
+	text[textPos] = index.alpha.letters[newSym]
+	s := &substitution{
+		bwt:  append([]byte{}, index.bwt...),
+		sa:   append([]int32{}, index.sa...),
+		isa:  make([]int32, len(index.sa)),
+		ctab: &CTab{append([]int{}, index.ctab.cumsum...)},
+		otab: &OTab{otab.nrow, otab.ncol, append([]int{}, otab.table...)},
+	}
+	for row, pos := range s.sa {
+		s.isa[pos] = int32(row)
+	}
+
+	// The row of the suffix after textPos has x[textPos] in the BWT.
+	k := int(s.isa[textPos+1])
+	j := int(s.isa[textPos]) // the current row of the suffix at textPos
+	s.replace(k, newSym)
+	jp := s.lf(k) // and the row it belongs in now
+
+	for j != jp {
+		s.move(j, jp)
+		// The suffix before the moved one is the next to move, if it is
+		// out of place.
+		if pos := s.sa[jp]; pos > 0 {
+			j = int(s.isa[pos-1])
+		} else {
+			j = 0
+		}
+		jp = s.lf(jp)
+	}
+
+	return &Index{
+		text:         string(text),
+		sa:           s.sa,
+		bwt:          s.bwt,
+		alpha:        index.alpha,
+		ctab:         s.ctab,
+		otab:         s.otab,
+		doubleStrand: index.doubleStrand,
+	}
+}
+
+// substitution is the state of a BWT being updated by SubstituteChar.
+type substitution struct {
+	bwt  []byte
+	sa   []int32
+	isa  []int32 // the inverse of sa, so isa[sa[i]] == i
+	ctab *CTab
+	otab *OTab
+}
+
+// lf is the LF-mapping of row i, with the sentinel's row mapping to 0.
+func (s *substitution) lf(i int) int {
+	a := s.bwt[i]
+	if a == 0 {
+		return 0
+	}
+	return s.ctab.Rank(a) + s.otab.Rank(a, i)
+}
+
+// replace changes the BWT symbol in row i to a.
+func (s *substitution) replace(i int, a byte) {
+	old := s.bwt[i]
+	s.bwt[i] = a
+	for p := i; p < s.otab.ncol; p++ {
+		s.otab.set(old, p, s.otab.get(old, p)-1)
+		s.otab.set(a, p, s.otab.get(a, p)+1)
+	}
+	for b := int(old) + 1; b < len(s.ctab.cumsum); b++ {
+		s.ctab.cumsum[b]--
+	}
+	for b := int(a) + 1; b < len(s.ctab.cumsum); b++ {
+		s.ctab.cumsum[b]++
+	}
+}
+
+// move deletes row from and inserts it again so it ends up at row to,
+// shifting the rows in between.
+func (s *substitution) move(from, to int) {
+	a, pos := s.bwt[from], s.sa[from]
+	if from < to {
+		copy(s.bwt[from:to], s.bwt[from+1:to+1])
+		copy(s.sa[from:to], s.sa[from+1:to+1])
+		for b := byte(1); int(b) <= s.otab.nrow; b++ {
+			for p := from; p < to; p++ {
+				rank := s.otab.get(b, p+1)
+				if b == a {
+					rank--
+				}
+				s.otab.set(b, p, rank)
+			}
+		}
+	} else {
+		copy(s.bwt[to+1:from+1], s.bwt[to:from])
+		copy(s.sa[to+1:from+1], s.sa[to:from])
+		for b := byte(1); int(b) <= s.otab.nrow; b++ {
+			for p := from - 1; p >= to; p-- {
+				rank := s.otab.Rank(b, p)
+				if b == a {
+					rank++
+				}
+				s.otab.set(b, p, rank)
+			}
+		}
+	}
+	s.bwt[to], s.sa[to] = a, pos
+
+	lo, hi := from, to
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	for p := lo; p <= hi; p++ {
+		s.isa[s.sa[p]] = int32(p)
+	}
+}
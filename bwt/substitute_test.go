@@ -0,0 +1,92 @@
+package bwt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubstituteChar(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 50; i++ {
+		x := []byte(randomStringN(1+rng.Intn(60), "acgt", rng))
+		// The unedited suffix keeps every letter in the alphabet, so the
+		// tables can be compared with those of a rebuilt index.
+		n := len(x)
+		x = append(x, "acgt"...)
+		idx := BuildIndex(string(x))
+		for e := 0; e < 5; e++ {
+			pos, a := rng.Intn(n), "acgt"[rng.Intn(4)]
+			updated, err := SubstituteChar(idx, pos, a)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			x[pos] = a
+			expected := BuildIndex(string(x))
+			if !reflect.DeepEqual(updated.bwt, expected.bwt) {
+				t.Fatalf("Expected the BWT %v of %q, got %v", expected.bwt, x, updated.bwt)
+			}
+			if !reflect.DeepEqual(updated.sa, expected.sa) {
+				t.Fatalf("Expected the suffix array %v of %q, got %v", expected.sa, x, updated.sa)
+			}
+			if !reflect.DeepEqual(updated.ctab, expected.ctab) || !reflect.DeepEqual(updated.otab, expected.otab) {
+				t.Fatalf("Expected the tables of %q after the substitution", x)
+			}
+			if updated.Extract(0, updated.Len()) != string(x) {
+				t.Fatalf("Expected the text %q, got %q", x, updated.Extract(0, updated.Len()))
+			}
+			idx = updated
+		}
+	}
+}
+
+func TestSubstituteCharErrors(t *testing.T) {
+	idx := BuildIndex("acgt")
+	if _, err := SubstituteChar(idx, 4, 'a'); err == nil {
+		t.Error("Expected an error for a position outside the text")
+	}
+	if _, err := SubstituteChar(idx, 0, 'x'); err == nil {
+		t.Error("Expected an error for a character outside the alphabet")
+	}
+	if _, err := SubstituteChar(BuildIndexCompact("acgt"), 0, 'c'); err == nil {
+		t.Error("Expected an error for a compact index")
+	}
+	if idx.Extract(0, 4) != "acgt" {
+		t.Error("Expected the original index to be unchanged")
+	}
+
+	ds := BuildDoubleStrandIndex("acgt")
+	for _, pos := range []int{4, 5, 8} { // the separator and the reverse strand
+		if _, err := SubstituteChar(ds, pos, 'a'); err == nil {
+			t.Errorf("Expected an error for position %d outside the forward strand", pos)
+		}
+	}
+	if _, err := SubstituteChar(ds, 0, strandSeparator); err == nil {
+		t.Error("Expected an error for substituting the strand separator")
+	}
+}
+
+func TestSubstituteCharDoubleStrand(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 20; i++ {
+		x := []byte(randomStringN(1+rng.Intn(40), "acgt", rng))
+		n := len(x)
+		x = append(x, "acgt"...) // keeps the alphabet, as in TestSubstituteChar
+		idx := BuildDoubleStrandIndex(string(x))
+		for e := 0; e < 3; e++ {
+			pos, a := rng.Intn(n), "acgt"[rng.Intn(4)]
+			updated, err := SubstituteChar(idx, pos, a)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			x[pos] = a
+			expected := BuildDoubleStrandIndex(string(x))
+			if !reflect.DeepEqual(updated.bwt, expected.bwt) || !reflect.DeepEqual(updated.sa, expected.sa) {
+				t.Fatalf("Expected the double-strand index of %q after the substitution", x)
+			}
+			if updated.text != expected.text {
+				t.Fatalf("Expected the text %q, got %q", expected.text, updated.text)
+			}
+			idx = updated
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package bwt
+
+import "fmt"
+
+// CTab is the C-table of a BWT: for each character a, the number of
+// characters in the BWT that are smaller than a.
+type CTab struct {
+	cumsum []int
+}
+
+// NewCTab builds the C-table for bwt over an alphabet of size asize.
+func NewCTab(bwt []byte, asize int) *CTab {
+	counts := make([]int, asize)
+	for _, a := range bwt {
+		counts[a]++
+	}
+	acc := 0
+	for a, count := range counts {
+		counts[a] = acc
+		acc += count
+	}
+	return &CTab{counts}
+}
+
+// Rank returns the number of characters in the BWT smaller than a.
+func (ctab *CTab) Rank(a byte) int {
+	return ctab.cumsum[a]
+}
+
+// OTab is the O-table of a BWT: for each character a and index i, the
+// number of occurrences of a in bwt[:i]. The sentinel (character 0) is
+// never looked up, so its row is not stored.
+type OTab struct {
+	nrow, ncol int
+	table      []int
+}
+
+func (otab *OTab) offset(a byte, i int) int {
+	// Row-major, so the ranks for a single character are adjacent
+	return otab.ncol*(int(a)-1) + i
+}
+
+func (otab *OTab) get(a byte, i int) int {
+	return otab.table[otab.offset(a, i)]
+}
+
+func (otab *OTab) set(a byte, i, val int) {
+	otab.table[otab.offset(a, i)] = val
+}
+
+// NewOTab builds the O-table for bwt over an alphabet of size asize.
+func NewOTab(bwt []byte, asize int) *OTab {
+	nrow, ncol := asize-1, len(bwt)
+	otab := &OTab{nrow, ncol, make([]int, nrow*ncol)}
+	for a := 1; a < asize; a++ {
+		count := 0
+		for i, b := range bwt {
+			if b == byte(a) {
+				count++
+			}
+			otab.set(byte(a), i, count)
+		}
+	}
+	return otab
+}
+
+// Rank returns the number of occurrences of a in bwt[:i].
+func (otab *OTab) Rank(a byte, i int) int {
+	if i == 0 {
+		return 0
+	}
+	return otab.get(a, i-1)
+}
+
+// RangeRank returns the number of occurrences of a in bwt[lo:hi]. It
+// panics unless 0 <= lo <= hi <= len(bwt).
+func (otab *OTab) RangeRank(a byte, lo, hi int) int {
+	if lo < 0 || hi < lo || hi > otab.ncol {
+		panic("bwt: rank range out of bounds")
+	}
+	return otab.Rank(a, hi) - otab.Rank(a, lo)
+}
+
+// SparseOTab is an O-table that stores the ranks only at every blockSize'th
+// position of the BWT and keeps a reference to the BWT for the rest: the
+// rank at i is the stored rank at the last checkpoint at or before i plus
+// the occurrences counted in the at most blockSize-1 characters from there
+// to i. The table takes (asize-1)*(n/blockSize+1) entries instead of
+// (asize-1)*n, at the cost of that scan on every lookup, and it has the
+// same Rank method as OTab, so it works in its place in backward search.
+type SparseOTab struct {
+	bwt         []byte
+	blockSize   int
+	checkpoints []int // checkpoints[(a-1)*nblocks+k] is the rank of a at k*blockSize
+	nblocks     int
+}
+
+// NewSparseOTab builds the O-table for bwt over an alphabet of size asize,
+// with a checkpoint every blockSize positions. A blockSize below 1 is
+// taken as 1, which stores every rank like OTab.
+func NewSparseOTab(bwt []byte, asize, blockSize int) *SparseOTab {
+	if blockSize < 1 {
+		blockSize = 1
+	}
+	nblocks := len(bwt)/blockSize + 1
+	otab := &SparseOTab{bwt, blockSize, make([]int, (asize-1)*nblocks), nblocks}
+	counts := make([]int, asize)
+	for i := 0; i <= len(bwt); i++ {
+		if i%blockSize == 0 {
+			for a := 1; a < asize; a++ {
+				otab.checkpoints[(a-1)*nblocks+i/blockSize] = counts[a]
+			}
+		}
+		if i < len(bwt) {
+			counts[bwt[i]]++
+		}
+	}
+	return otab
+}
+
+// Rank returns the number of occurrences of a in bwt[:i].
+func (otab *SparseOTab) Rank(a byte, i int) int {
+	k := i / otab.blockSize
+	rank := otab.checkpoints[(int(a)-1)*otab.nblocks+k]
+	for _, b := range otab.bwt[k*otab.blockSize : i] {
+		if b == a {
+			rank++
+		}
+	}
+	return rank
+}
+
+// RangeRank returns the number of occurrences of a in bwt[lo:hi]. It
+// panics unless 0 <= lo <= hi <= len(bwt).
+func (otab *SparseOTab) RangeRank(a byte, lo, hi int) int {
+	if lo < 0 || hi < lo || hi > len(otab.bwt) {
+		panic("bwt: rank range out of bounds")
+	}
+	return otab.Rank(a, hi) - otab.Rank(a, lo)
+}
+
+// SizeBytes returns the size of the checkpoints in bytes, not counting
+// the BWT the table refers to.
+func (otab *SparseOTab) SizeBytes() int {
+	return len(otab.checkpoints) * intSize
+}
+
+// Count returns the number of occurrences of p in the text whose BWT the
+// tables were built from, by backward search. The characters of p are
+// looked up in the tables as they are, so p must use the same symbols as
+// the BWT: raw bytes for tables built from Bwt with asize 256, or mapped
+// symbols for tables over an alphabet. The empty pattern occurs at every
+// row, for a count of len(bwt), and a character outside the tables, or
+// the sentinel, gives 0.
+func Count(p string, ctab *CTab, otab *OTab) int {
+	return tableCount(p, ctab, otab)
+}
+
+// CountBytes is Count for a pattern given as a byte slice.
+func CountBytes(p []byte, ctab *CTab, otab *OTab) int {
+	return tableCount(p, ctab, otab)
+}
+
+func tableCount[P pattern](p P, ctab *CTab, otab *OTab) int {
+	lo, hi := tableSearch(p, ctab, otab)
+	return hi - lo
+}
+
+// Locate returns the positions in the text where p occurs, looking p up
+// in the tables like Count and reading the positions from the suffix
+// array sa that the BWT was built from, such as the one from
+// PrefixDoubling. The positions are in suffix-array order, not sorted.
+// The sentinel's position, len(sa)-1, which only the empty pattern
+// reaches, is left out, and no matches give an empty slice.
+func Locate(p string, sa []int32, ctab *CTab, otab *OTab) []int {
+	return tableLocate(p, sa, ctab, otab)
+}
+
+// LocateBytes is Locate for a pattern given as a byte slice.
+func LocateBytes(p []byte, sa []int32, ctab *CTab, otab *OTab) []int {
+	return tableLocate(p, sa, ctab, otab)
+}
+
+func tableLocate[P pattern](p P, sa []int32, ctab *CTab, otab *OTab) []int {
+	lo, hi := tableSearch(p, ctab, otab)
+	positions := make([]int, 0, hi-lo)
+	for _, pos := range sa[lo:hi] {
+		if int(pos) != len(sa)-1 {
+			positions = append(positions, int(pos))
+		}
+	}
+	return positions
+}
+
+// tableSearch finds the interval [lo, hi) of p for Count and Locate.
+func tableSearch[P pattern](p P, ctab *CTab, otab *OTab) (lo, hi int) {
+	lo, hi = 0, otab.ncol
+	for i := len(p) - 1; i >= 0 && lo < hi; i-- {
+		a := p[i]
+		if a == 0 || int(a) > otab.nrow {
+			return 0, 0
+		}
+		lo = ctab.Rank(a) + otab.Rank(a, lo)
+		hi = ctab.Rank(a) + otab.Rank(a, hi)
+	}
+	return lo, hi
+}
+
+// verifyStep is the distance between the positions VerifyOTab checks.
+const verifyStep = 64
+
+// VerifyOTab checks that otab is the O-table of bwt over an alphabet of
+// size asize. It checks the table's dimensions and then compares the ranks
+// of all characters at every 64th position, and at the end of the BWT,
+// with ranks counted directly from bwt, so the check runs in
+// O(n + asize*n/64) time. Entries between the checked positions are not
+// inspected, so a table with isolated corrupted entries can pass.
+func VerifyOTab(bwt []byte, otab *OTab, asize int) error {
+	if otab.nrow != asize-1 || otab.ncol != len(bwt) {
+		return fmt.Errorf("O-table is %dx%d, expected %dx%d for the BWT",
+			otab.nrow, otab.ncol, asize-1, len(bwt))
+	}
+
+	counts := make([]int, asize)
+	for i := 0; i <= len(bwt); i++ {
+		if i%verifyStep == 0 || i == len(bwt) {
+			for a := 1; a < asize; a++ {
+				if rank := otab.Rank(byte(a), i); rank != counts[a] {
+					return fmt.Errorf("Rank(%d, %d) is %d, expected %d", a, i, rank, counts[a])
+				}
+			}
+		}
+		if i < len(bwt) {
+			if int(bwt[i]) >= asize {
+				return fmt.Errorf("symbol %d at index %d is outside the alphabet", bwt[i], i)
+			}
+			counts[bwt[i]]++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package bwt
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestVerifyOTab(t *testing.T) {
+	rng := newRandomSeed(t)
+	idx := BuildIndex(randomStringN(500, "acgt", rng))
+	bwt, asize := idx.BWT(), idx.Alphabet().Size()
+
+	otab := NewOTab(bwt, asize)
+	if err := VerifyOTab(bwt, otab, asize); err != nil {
+		t.Fatalf("Expected NewOTab's table to verify, got %v", err)
+	}
+
+	if err := VerifyOTab(bwt, otab, asize+1); err == nil {
+		t.Errorf("Expected an error for the wrong alphabet size")
+	}
+	if err := VerifyOTab(bwt[1:], otab, asize); err == nil {
+		t.Errorf("Expected an error for a BWT of the wrong length")
+	}
+
+	other := append([]byte{}, bwt...)
+	other[0], other[len(other)-1] = other[len(other)-1], other[0]
+	if other[0] != other[len(other)-1] {
+		if err := VerifyOTab(other, otab, asize); err == nil {
+			t.Errorf("Expected an error for a mismatched BWT")
+		}
+	}
+
+	otab.set(2, verifyStep-1, otab.get(2, verifyStep-1)+1)
+	if err := VerifyOTab(bwt, otab, asize); err == nil {
+		t.Errorf("Expected an error for a tampered table")
+	}
+}
+
+func TestRangeRank(t *testing.T) {
+	rng := newRandomSeed(t)
+	idx := BuildIndex(randomStringN(300, "acgt", rng))
+	bwt, asize := idx.BWT(), idx.Alphabet().Size()
+	otab := NewOTab(bwt, asize)
+	for trial := 0; trial < 200; trial++ {
+		lo := rng.Intn(len(bwt) + 1)
+		hi := lo + rng.Intn(len(bwt)-lo+1)
+		for a := 1; a < asize; a++ {
+			expected := 0
+			for _, b := range bwt[lo:hi] {
+				if b == byte(a) {
+					expected++
+				}
+			}
+			if got := otab.RangeRank(byte(a), lo, hi); got != expected {
+				t.Errorf("Expected %d occurrences of %d in [%d,%d), got %d", expected, a, lo, hi, got)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a reversed range")
+		}
+	}()
+	otab.RangeRank(1, 2, 1)
+}
+
+func TestCountTables(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(300, "acgt", rng)
+	b := []byte(Bwt(x))
+	ctab, otab := NewCTab(b, 256), NewOTab(b, 256)
+	idx := BuildIndex(x)
+	for i := 0; i < 50; i++ {
+		p := randomStringN(rng.Intn(6), "acgt", rng)
+		if count, expected := Count(p, ctab, otab), idx.Count(p); count != expected {
+			t.Errorf("Expected Count(%q) = %d, got %d", p, expected, count)
+		}
+		if count, expected := CountBytes([]byte(p), ctab, otab), idx.Count(p); count != expected {
+			t.Errorf("Expected CountBytes(%q) = %d, got %d", p, expected, count)
+		}
+	}
+
+	if count := Count("", ctab, otab); count != len(x)+1 {
+		t.Errorf("Expected the empty pattern to count %d rows, got %d", len(x)+1, count)
+	}
+	if count := Count(x+"a", ctab, otab); count != 0 {
+		t.Errorf("Expected no matches for a pattern longer than the text, got %d", count)
+	}
+	if count := Count(x, ctab, otab); count != 1 {
+		t.Errorf("Expected the text to occur once, got %d", count)
+	}
+	for _, p := range []string{"ax", "\x00", "\xff"} {
+		if count := Count(p, ctab, otab); count != 0 {
+			t.Errorf("Expected no matches for %q, got %d", p, count)
+		}
+	}
+
+	// Tables over a mapped alphabet have no rows for larger symbols.
+	small := []byte{1, 0, 1}
+	if count := Count("\x05", NewCTab(small, 2), NewOTab(small, 2)); count != 0 {
+		t.Errorf("Expected no matches for a symbol outside the tables, got %d", count)
+	}
+}
+
+func TestLocateTables(t *testing.T) {
+	rng := newRandomSeed(t)
+	x := randomStringN(300, "acgt", rng)
+	b, sa := BwtWithSA(x)
+	ctab, otab := NewCTab([]byte(b), 256), NewOTab([]byte(b), 256)
+	for i := 0; i < 50; i++ {
+		p := randomStringN(1+rng.Intn(5), "acgt", rng)
+		positions := Locate(p, sa, ctab, otab)
+		sort.Ints(positions)
+		expected := []int{}
+		for _, pos := range naiveOccurrences(x, p) {
+			expected = append(expected, int(pos))
+		}
+		if !reflect.DeepEqual(positions, expected) {
+			t.Errorf("Expected Locate(%q) = %v, got %v", p, expected, positions)
+		}
+		positions = LocateBytes([]byte(p), sa, ctab, otab)
+		sort.Ints(positions)
+		if !reflect.DeepEqual(positions, expected) {
+			t.Errorf("Expected LocateBytes(%q) = %v, got %v", p, expected, positions)
+		}
+	}
+
+	if positions := Locate("", sa, ctab, otab); len(positions) != len(x) {
+		t.Errorf("Expected the empty pattern at the %d positions of the text, got %d", len(x), len(positions))
+	}
+	for _, p := range []string{x + "a", "ax", "\x00"} {
+		if positions := Locate(p, sa, ctab, otab); positions == nil || len(positions) != 0 {
+			t.Errorf("Expected an empty, non-nil result for %q, got %#v", p, positions)
+		}
+	}
+}
+
+func TestSparseOTab(t *testing.T) {
+	rng := newRandomSeed(t)
+	for i := 0; i < 10; i++ {
+		idx := BuildIndex(randomStringN(rng.Intn(300), "acgt", rng))
+		bwt, asize := idx.BWT(), idx.Alphabet().Size()
+		dense := NewOTab(bwt, asize)
+		for _, blockSize := range []int{0, 1, 2, 7, 64, 1000} {
+			sparse := NewSparseOTab(bwt, asize, blockSize)
+			for a := byte(1); int(a) < asize; a++ {
+				for j := 0; j <= len(bwt); j++ {
+					if rank, expected := sparse.Rank(a, j), dense.Rank(a, j); rank != expected {
+						t.Fatalf("Expected Rank(%d, %d) = %d with blocks of %d, got %d", a, j, expected, blockSize, rank)
+					}
+				}
+			}
+			if blockSize == 64 && len(bwt) > 128 && sparse.SizeBytes() >= dense.SizeBytes() {
+				t.Errorf("Expected the sparse table to be smaller, got %d bytes against %d", sparse.SizeBytes(), dense.SizeBytes())
+			}
+		}
+	}
+
+	// It works in place of the dense table in backward search.
+	x := randomStringN(500, "acgt", rng)
+	idx := BuildIndex(x)
+	sparse := NewSparseOTab(idx.BWT(), idx.Alphabet().Size(), 32)
+	for i := 0; i < 20; i++ {
+		p := randomStringN(1+rng.Intn(4), "acgt", rng)
+		L, R := backwardSearch(p, len(idx.BWT()), idx.Alphabet(), idx.ctab, sparse)
+		if R-L != idx.Count(p) {
+			t.Errorf("Expected %d occurrences of %q with the sparse table, got %d", idx.Count(p), p, R-L)
+		}
+	}
+}
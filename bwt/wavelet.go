@@ -0,0 +1,135 @@
+package bwt
+
+// waveletMatrix is a wavelet tree over a sequence of non-negative integers
+// in the level-wise layout of a wavelet matrix: level l holds one bit per
+// element, bit l from the top of its value, and the next level reorders
+// the elements stably with those with a 0 bit first. Unlike a pointer
+// based wavelet tree, the levels are single bit vectors, so each level of
+// a query is a pair of rank lookups.
+type waveletMatrix struct {
+	n      int
+	levels []*Bitvector
+	zeros  []int // zeros[l] is the number of 0 bits on level l
+}
+
+// newWaveletMatrix builds the wavelet matrix for values.
+func newWaveletMatrix(values []int32) *waveletMatrix {
+	maxValue := int32(0)
+	for _, v := range values {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+	nlevels := 1
+	for 1<<nlevels <= int(maxValue) {
+		nlevels++
+	}
+
+	wm := &waveletMatrix{n: len(values)}
+	cur := append([]int32{}, values...)
+	next := make([]int32, len(values))
+	for l := 0; l < nlevels; l++ {
+		shift := uint(nlevels - 1 - l)
+		bv := newBitvector(len(cur))
+		zeros := 0
+		for i, v := range cur {
+			if (v>>shift)&1 == 1 {
+				bv.set(i)
+			} else {
+				zeros++
+			}
+		}
+		bv.finish()
+		z, o := 0, zeros
+		for _, v := range cur {
+			if (v>>shift)&1 == 1 {
+				next[o] = v
+				o++
+			} else {
+				next[z] = v
+				z++
+			}
+		}
+		wm.levels = append(wm.levels, bv)
+		wm.zeros = append(wm.zeros, zeros)
+		cur, next = next, cur
+	}
+	return wm
+}
+
+// countLess returns the number of values smaller than v among the
+// elements [L, R).
+func (wm *waveletMatrix) countLess(L, R int, v int32) int {
+	if v <= 0 {
+		return 0
+	}
+	if int(v) >= 1<<len(wm.levels) {
+		return R - L
+	}
+	count := 0
+	for l, bv := range wm.levels {
+		bit := (v >> uint(len(wm.levels)-1-l)) & 1
+		l0, r0 := L-bv.Rank(L), R-bv.Rank(R)
+		if bit == 1 {
+			// The elements with a 0 bit here are all smaller than v.
+			count += r0 - l0
+			L, R = wm.zeros[l]+(L-l0), wm.zeros[l]+(R-r0)
+		} else {
+			L, R = l0, r0
+		}
+	}
+	return count
+}
+
+// rangeCount returns the number of values in [lo, hi) among the elements
+// [L, R), in O(log max) time.
+func (wm *waveletMatrix) rangeCount(L, R int, lo, hi int32) int {
+	if lo >= hi {
+		return 0
+	}
+	return wm.countLess(L, R, hi) - wm.countLess(L, R, lo)
+}
+
+// sizeBytes returns the size of the matrix's payload in bytes.
+func (wm *waveletMatrix) sizeBytes() int {
+	size := len(wm.zeros) * intSize
+	for _, bv := range wm.levels {
+		size += bv.SizeBytes()
+	}
+	return size
+}
+
+// waveletOTab answers the O-table's rank queries from a wavelet matrix
+// over the BWT. Each query takes O(log sigma) time instead of O(1), but
+// the matrix takes about n*log2(sigma) bits rather than sigma*n ints.
+type waveletOTab struct {
+	wm *waveletMatrix
+}
+
+// newWaveletOTab builds the wavelet O-table for bwt.
+func newWaveletOTab(bwt []byte) *waveletOTab {
+	values := make([]int32, len(bwt))
+	for i, a := range bwt {
+		values[i] = int32(a)
+	}
+	return &waveletOTab{newWaveletMatrix(values)}
+}
+
+// Rank returns the number of occurrences of a in bwt[:i].
+func (otab *waveletOTab) Rank(a byte, i int) int {
+	return otab.wm.rangeCount(0, i, int32(a), int32(a)+1)
+}
+
+// RangeRank returns the number of occurrences of a in bwt[lo:hi]. It
+// panics unless 0 <= lo <= hi <= len(bwt).
+func (otab *waveletOTab) RangeRank(a byte, lo, hi int) int {
+	if lo < 0 || hi < lo || hi > otab.wm.n {
+		panic("bwt: rank range out of bounds")
+	}
+	return otab.wm.rangeCount(lo, hi, int32(a), int32(a)+1)
+}
+
+// SizeBytes returns the size of the table's payload in bytes.
+func (otab *waveletOTab) SizeBytes() int {
+	return otab.wm.sizeBytes()
+}